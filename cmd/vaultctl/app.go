@@ -35,6 +35,12 @@ func newVaultCtl() *cli.App {
 		newSyncCommand(),
 		newTransitCommand(),
 		newKubeCommand(),
+		newValidateCommand(),
+		newExportCommand(),
+		newImportCommand(),
+		newSecretCommand(),
+		newRenderCommand(),
+		newExecCommand(),
 	}
 
 	return app
@@ -26,6 +26,13 @@ const (
 	Version = "v0.0.6"
 )
 
+const (
+	// defaultTarget is the name of the target when none are defined in the configuration
+	defaultTarget = "default"
+	// generatedPasswordLength is the length of the passwords generated for users
+	generatedPasswordLength = 32
+)
+
 type resources struct {
 	// a collection of auths
 	auths []*api.Auth
@@ -37,4 +44,8 @@ type resources struct {
 	secrets []*api.Secret
 	// a collection of policies
 	policies []*api.Policy
+	// a collection of vault targets
+	targets []*api.Target
+	// the password policy for userpass users
+	passwordPolicy *api.PasswordPolicy
 }
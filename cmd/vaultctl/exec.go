@@ -0,0 +1,165 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/utils"
+	"github.com/UKHomeOffice/vaultctl/pkg/vault"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+)
+
+// forwardedSignals are the signals relayed to the child process
+var forwardedSignals = []os.Signal{
+	syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT, syscall.SIGUSR1, syscall.SIGUSR2,
+}
+
+type execCommand struct {
+	// the vault client
+	client *vault.Client
+}
+
+// newExecCommand creates a new exec command
+func newExecCommand() cli.Command {
+	return new(execCommand).getCommand()
+}
+
+// action runs the command with the secrets exported as environment variables
+func (r *execCommand) action(cx *cli.Context) error {
+	specs := cx.StringSlice("secret")
+	if len(specs) <= 0 {
+		return fmt.Errorf("you have not specified any secrets")
+	}
+	if len(cx.Args()) <= 0 {
+		return fmt.Errorf("you have not specified a command to run, i.e. exec --secret path -- command args")
+	}
+
+	client, err := getVaultClient(cx)
+	if err != nil {
+		return err
+	}
+	r.client = client
+
+	environment, err := r.getEnvironment(specs)
+	if err != nil {
+		return err
+	}
+
+	// step: start the child process
+	cmd := exec.Command(cx.Args()[0], cx.Args()[1:]...)
+	cmd.Env = append(os.Environ(), environment...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start the command: %s, error: %s", cx.Args()[0], err)
+	}
+	// step: forward the signals to the child
+	go func() {
+		for s := range signals {
+			cmd.Process.Signal(s)
+		}
+	}()
+
+	err = cmd.Wait()
+	signal.Stop(signals)
+	close(signals)
+
+	// step: exit with the exit code of the child
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return err
+		}
+		status, ok := exitErr.Sys().(syscall.WaitStatus)
+		if !ok {
+			return err
+		}
+		if status.Signaled() {
+			os.Exit(128 + int(status.Signal()))
+		}
+		os.Exit(status.ExitStatus())
+	}
+
+	return nil
+}
+
+// getEnvironment reads the secrets and returns the environment variables, where a spec is
+// path[:prefix]
+func (r *execCommand) getEnvironment(specs []string) ([]string, error) {
+	var environment []string
+	names := make(map[string]string, 0)
+	for _, x := range specs {
+		items := strings.SplitN(x, ":", 2)
+		path := items[0]
+		prefix := ""
+		if len(items) == 2 {
+			prefix = items[1]
+		}
+		values, err := r.client.GetSecret(path)
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			return nil, fmt.Errorf("the secret: %s does not exist", path)
+		}
+		var keys []string
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			name := utils.EnvName(prefix, k)
+			if previous, found := names[name]; found {
+				log.Warnf("the environment variable: %s from: %s is overriding the value from: %s", name, path, previous)
+			}
+			names[name] = path
+			environment = append(environment, fmt.Sprintf("%s=%v", name, values[k]))
+		}
+	}
+
+	return environment, nil
+}
+
+// getCommand returns the command set
+func (r *execCommand) getCommand() cli.Command {
+	return cli.Command{
+		Name:      "exec",
+		Usage:     "runs a command with the secrets exported as environment variables",
+		ArgsUsage: "-- COMMAND [ARGS...]",
+		Flags: []cli.Flag{
+			cli.StringSliceFlag{
+				Name:  "s, secret",
+				Usage: "the path of a secret to export, with an optional prefix, path[:prefix], can be specified multiple times",
+			},
+		},
+		Action: func(cx *cli.Context) {
+			executeCommand(cx, r.action)
+		},
+	}
+}
@@ -0,0 +1,304 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/api"
+	"github.com/UKHomeOffice/vaultctl/pkg/utils"
+	"github.com/UKHomeOffice/vaultctl/pkg/vault"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+)
+
+const (
+	// maskedValue is the value used in place of a secret when masking
+	maskedValue = "********"
+)
+
+var (
+	// hclIdentifierRegex is the format of a hcl key which does not need quoting
+	hclIdentifierRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_\-]*$`)
+)
+
+type exportCommand struct {
+	// the vault client
+	client *vault.Client
+	// the output format of the document
+	format string
+	// the file to write the document to, defaults to stdout
+	output string
+	// a directory to write a file per secret
+	outputDir string
+	// whether to mask the values
+	mask bool
+	// the transit path used to encrypt the values
+	transit string
+	// the transit key used to encrypt the values
+	key string
+}
+
+// exportDocument is the config document containing the exported secrets
+type exportDocument struct {
+	// Secrets is a series of secrets
+	Secrets []*api.Secret `yaml:"secrets" json:"secrets" hcl:"secrets"`
+}
+
+// newExportCommand creates a new export command
+func newExportCommand() cli.Command {
+	return new(exportCommand).getCommand()
+}
+
+// action exports the secrets under the paths
+func (r *exportCommand) action(cx *cli.Context) error {
+	paths := cx.StringSlice("path")
+	if len(paths) <= 0 {
+		return fmt.Errorf("you have not specified any paths to export")
+	}
+	if !utils.ContainedIn(r.format, []string{"yaml", "json", "hcl"}) {
+		return fmt.Errorf("unsupported format: %s, must be yaml, json or hcl", r.format)
+	}
+	if r.mask && r.transit != "" {
+		return fmt.Errorf("you cannot both mask and encrypt the values")
+	}
+	if r.transit != "" && r.key == "" {
+		return fmt.Errorf("you have not specified a transit key")
+	}
+	if r.output != "" && r.outputDir != "" {
+		return fmt.Errorf("you cannot specify both an output file and directory")
+	}
+
+	client, err := getVaultClient(cx)
+	if err != nil {
+		return err
+	}
+	r.client = client
+
+	// step: retrieve the secrets under the paths
+	var secrets []*api.Secret
+	for _, path := range paths {
+		list, err := r.client.ListSecrets(path)
+		if err != nil {
+			return fmt.Errorf("failed to list the secrets under: %s, error: %s", path, err)
+		}
+		var keys []string
+		for k := range list {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, x := range keys {
+			values := list[x]
+			if err := r.protectValues(values); err != nil {
+				return fmt.Errorf("failed to encrypt the secret: %s, error: %s", x, err)
+			}
+			secrets = append(secrets, &api.Secret{Path: x, Values: values})
+		}
+	}
+	log.Infof("exporting %d secrets", len(secrets))
+
+	// step: write a file per secret
+	if r.outputDir != "" {
+		for _, x := range secrets {
+			filename := filepath.Join(r.outputDir, fmt.Sprintf("%s.%s", x.Path, r.format))
+			if err := r.writeDocument(filename, &exportDocument{Secrets: []*api.Secret{x}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return r.writeDocument(r.output, &exportDocument{Secrets: secrets})
+}
+
+// protectValues masks or encrypts the values of the secret if requested
+func (r *exportCommand) protectValues(values map[string]interface{}) error {
+	for k, v := range values {
+		switch {
+		case r.mask:
+			values[k] = maskedValue
+		case r.transit != "":
+			value, ok := v.(string)
+			if !ok {
+				encoded, err := json.Marshal(v)
+				if err != nil {
+					return err
+				}
+				value = string(encoded)
+			}
+			encrypted, err := r.client.Encrypt(r.transit, r.key, strings.NewReader(value))
+			if err != nil {
+				return err
+			}
+			values[k] = encrypted
+		}
+	}
+
+	return nil
+}
+
+// writeDocument encodes the document and writes it to the file or stdout
+func (r *exportCommand) writeDocument(filename string, document *exportDocument) error {
+	return writeSecretsDocument(filename, r.format, document)
+}
+
+// writeSecretsDocument encodes a secrets document in the format and writes it to the file or stdout
+func writeSecretsDocument(filename, format string, document *exportDocument) error {
+	var content []byte
+	var err error
+	switch format {
+	case "hcl":
+		content = encodeSecretsHCL(document.Secrets)
+	default:
+		content, err = utils.EncodeConfig(document, format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if filename == "" {
+		fmt.Fprintf(os.Stdout, "%s", content)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0750); err != nil {
+		return err
+	}
+	log.Infof("writing the secrets document to: %s", filename)
+
+	return ioutil.WriteFile(filename, content, 0600)
+}
+
+// encodeSecretsHCL encodes the secrets as a hcl secrets document
+func encodeSecretsHCL(secrets []*api.Secret) []byte {
+	buf := new(bytes.Buffer)
+	for i, x := range secrets {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString("secrets {\n")
+		buf.WriteString(fmt.Sprintf("  path = %s\n", strconv.Quote(x.Path)))
+		buf.WriteString("  values ")
+		encodeHCLValue(buf, x.Values, "  ")
+		buf.WriteString("\n}\n")
+	}
+
+	return buf.Bytes()
+}
+
+// encodeHCLValue encodes a value in hcl
+func encodeHCLValue(buf *bytes.Buffer, value interface{}, indent string) {
+	switch x := value.(type) {
+	case map[string]interface{}:
+		var keys []string
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteString("{\n")
+		for _, k := range keys {
+			// step: hcl has no null, so the key is omitted rather than becoming an empty string
+			if x[k] == nil {
+				continue
+			}
+			name := k
+			if !hclIdentifierRegex.MatchString(k) {
+				name = strconv.Quote(k)
+			}
+			buf.WriteString(fmt.Sprintf("%s  %s = ", indent, name))
+			encodeHCLValue(buf, x[k], indent+"  ")
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "}")
+	case []interface{}:
+		buf.WriteString("[")
+		written := 0
+		for _, item := range x {
+			if item == nil {
+				continue
+			}
+			if written > 0 {
+				buf.WriteString(", ")
+			}
+			encodeHCLValue(buf, item, indent)
+			written++
+		}
+		buf.WriteString("]")
+	case string:
+		buf.WriteString(strconv.Quote(x))
+	case bool, int, int64, float64, json.Number:
+		buf.WriteString(fmt.Sprintf("%v", x))
+	default:
+		buf.WriteString(strconv.Quote(fmt.Sprintf("%v", x)))
+	}
+}
+
+// getCommand returns the command set
+func (r *exportCommand) getCommand() cli.Command {
+	return cli.Command{
+		Name:  "export",
+		Usage: "exports the generic secrets under a path as a secrets configuration document",
+		Flags: []cli.Flag{
+			cli.StringSliceFlag{
+				Name:  "P, path",
+				Usage: "the path of the secrets to recursively export, can be specified multiple times",
+			},
+			cli.StringFlag{
+				Name:        "f, format",
+				Usage:       "the format of the document, yaml, json or hcl",
+				Value:       "yaml",
+				Destination: &r.format,
+			},
+			cli.StringFlag{
+				Name:        "o, output",
+				Usage:       "the file to write the document to, defaults to stdout",
+				Destination: &r.output,
+			},
+			cli.StringFlag{
+				Name:        "output-dir",
+				Usage:       "a directory to write a document per secret, named after the secret path",
+				Destination: &r.outputDir,
+			},
+			cli.BoolFlag{
+				Name:        "m, mask",
+				Usage:       "mask the values of the secrets, exporting the paths and keys only",
+				Destination: &r.mask,
+			},
+			cli.StringFlag{
+				Name:        "t, transit",
+				Usage:       "the vault transit endpoint used to encrypt the values",
+				Destination: &r.transit,
+			},
+			cli.StringFlag{
+				Name:        "k, key",
+				Usage:       "the name of the key in the transit backend used to encrypt the values",
+				Destination: &r.key,
+			},
+		},
+		Action: func(cx *cli.Context) {
+			executeCommand(cx, r.action)
+		},
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeSecretsHCL(t *testing.T) {
+	tests := []struct {
+		Secrets  []*api.Secret
+		Expected string
+	}{
+		{
+			Secrets:  []*api.Secret{{Path: "secret/app", Values: map[string]interface{}{"password": "pa\"ss", "port": 5432}}},
+			Expected: "secrets {\n  path = \"secret/app\"\n  values {\n    password = \"pa\\\"ss\"\n    port = 5432\n  }\n}\n",
+		},
+		{
+			Secrets:  []*api.Secret{{Path: "secret/app", Values: map[string]interface{}{"db.user": "admin", "empty": nil}}},
+			Expected: "secrets {\n  path = \"secret/app\"\n  values {\n    \"db.user\" = \"admin\"\n  }\n}\n",
+		},
+		{
+			Secrets: []*api.Secret{{Path: "secret/app", Values: map[string]interface{}{
+				"hosts":   []interface{}{"a", nil, "b"},
+				"enabled": true,
+				"nested":  map[string]interface{}{"key": "value"},
+			}}},
+			Expected: "secrets {\n  path = \"secret/app\"\n  values {\n    enabled = true\n    hosts = [\"a\", \"b\"]\n" +
+				"    nested = {\n      key = \"value\"\n    }\n  }\n}\n",
+		},
+		{
+			Secrets: []*api.Secret{
+				{Path: "secret/a", Values: map[string]interface{}{"key": "a"}},
+				{Path: "secret/b", Values: map[string]interface{}{"key": "b"}},
+			},
+			Expected: "secrets {\n  path = \"secret/a\"\n  values {\n    key = \"a\"\n  }\n}\n" +
+				"\nsecrets {\n  path = \"secret/b\"\n  values {\n    key = \"b\"\n  }\n}\n",
+		},
+	}
+	for i, c := range tests {
+		assert.Equal(t, c.Expected, string(encodeSecretsHCL(c.Secrets)), "case %d not as expected", i)
+	}
+}
@@ -0,0 +1,343 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/api"
+	"github.com/UKHomeOffice/vaultctl/pkg/utils"
+	"github.com/UKHomeOffice/vaultctl/pkg/vault"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// yamlDocumentRegex is the separator between yaml documents
+	yamlDocumentRegex = regexp.MustCompile(`(?m)^---\s*$`)
+	// importFormats are the supported input formats
+	importFormats = []string{"dotenv", "properties", "json", "kube"}
+)
+
+type importCommand struct {
+	// the vault client
+	client *vault.Client
+	// the input format, detected from the file extension if not set
+	format string
+	// the template of the vault path
+	path string
+	// the name of the secret when it cannot be taken from the input
+	name string
+	// the namespace of the secret when it cannot be taken from the input
+	namespace string
+	// whether to write the secrets to vault rather than emit the config
+	write bool
+	// whether to replace the existing secrets in vault rather than merge into them
+	replace bool
+	// the format of the emitted config
+	outputFormat string
+	// the file to write the config to, defaults to stdout
+	output string
+}
+
+// importedSecret is a secret read from an input
+type importedSecret struct {
+	// the name of the secret
+	name string
+	// the namespace of the secret
+	namespace string
+	// the values of the secret
+	values map[string]interface{}
+}
+
+// kubeSecret is the subset of a kubernetes secret manifest we need
+type kubeSecret struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Data       map[string]string `yaml:"data"`
+	StringData map[string]string `yaml:"stringData"`
+}
+
+// newImportCommand creates a new import command
+func newImportCommand() cli.Command {
+	return new(importCommand).getCommand()
+}
+
+// action imports the secrets from the inputs
+func (r *importCommand) action(cx *cli.Context) error {
+	files := cx.StringSlice("file")
+	if len(files) <= 0 {
+		files = []string{"-"}
+	}
+	if r.format != "" && !utils.ContainedIn(r.format, importFormats) {
+		return fmt.Errorf("unsupported format: %s, must be one of %s", r.format, strings.Join(importFormats, ", "))
+	}
+	if r.path == "" {
+		return fmt.Errorf("you have not specified a path template")
+	}
+	if !r.write && !utils.ContainedIn(r.outputFormat, []string{"yaml", "json", "hcl"}) {
+		return fmt.Errorf("unsupported output format: %s, must be yaml, json or hcl", r.outputFormat)
+	}
+	if r.replace && !r.write {
+		return fmt.Errorf("the replace option is only applicable when writing the secrets to vault")
+	}
+
+	// step: read the secrets from the inputs
+	var secrets []*api.Secret
+	for _, filename := range files {
+		list, err := r.readInput(filename)
+		if err != nil {
+			return fmt.Errorf("unable to import: %s, error: %s", filename, err)
+		}
+		for _, x := range list {
+			path, err := r.renderPath(x)
+			if err != nil {
+				return err
+			}
+			secrets = append(secrets, &api.Secret{Path: path, Values: x.values})
+		}
+	}
+
+	if !r.write {
+		return writeSecretsDocument(r.output, r.outputFormat, &exportDocument{Secrets: secrets})
+	}
+
+	// step: write the secrets to vault
+	client, err := getVaultClient(cx)
+	if err != nil {
+		return err
+	}
+	r.client = client
+	for _, x := range secrets {
+		current, err := r.client.GetSecret(x.Path)
+		if err != nil {
+			return err
+		}
+		// step: keep the existing keys unless replacing the secret
+		if !r.replace {
+			x.Mode = api.MergeMode
+			x.Values = x.Reconcile(x.Values, current)
+		}
+		diff := x.Diff(current)
+		if !diff.HasChanged() {
+			log.Infof("[secret: %s] secret unchanged, skipping", x.Path)
+			continue
+		}
+		log.Infof("[secret: %s] importing the secret, added: [%s], changed: [%s], removed: [%s]", x.Path,
+			strings.Join(diff.Added, ","), strings.Join(diff.Changed, ","), strings.Join(diff.Removed, ","))
+		if err := r.client.AddSecret(x); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readInput reads the secrets from a file, or stdin if the filename is -
+func (r *importCommand) readInput(filename string) ([]*importedSecret, error) {
+	var content []byte
+	var err error
+	if filename == "-" {
+		content, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		content, err = ioutil.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// step: detect the format from the file extension
+	format := r.format
+	if format == "" {
+		switch filepath.Ext(filename) {
+		case ".env":
+			format = "dotenv"
+		case ".properties":
+			format = "properties"
+		case ".json":
+			format = "json"
+		case ".yml", ".yaml":
+			format = "kube"
+		default:
+			return nil, fmt.Errorf("unable to detect the format, please specify one")
+		}
+	}
+
+	// step: the name of the secret defaults to the file name
+	name := r.name
+	if name == "" && filename != "-" {
+		name = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+
+	var values map[string]interface{}
+	switch format {
+	case "kube":
+		return r.readKubeSecrets(content)
+	case "json":
+		if err := json.Unmarshal(content, &values); err != nil {
+			return nil, err
+		}
+	default:
+		var parsed map[string]string
+		if format == "dotenv" {
+			parsed, err = utils.ParseDotenv(string(content))
+		} else {
+			parsed, err = utils.ParseProperties(string(content))
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = make(map[string]interface{}, len(parsed))
+		for k, v := range parsed {
+			values[k] = v
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("unable to determine the name of the secret, please specify one")
+	}
+
+	return []*importedSecret{{name: name, namespace: r.namespace, values: values}}, nil
+}
+
+// readKubeSecrets reads the secrets from one or more kubernetes secret manifests
+func (r *importCommand) readKubeSecrets(content []byte) ([]*importedSecret, error) {
+	var list []*importedSecret
+	for _, document := range yamlDocumentRegex.Split(string(content), -1) {
+		if strings.TrimSpace(document) == "" {
+			continue
+		}
+		secret := new(kubeSecret)
+		if err := yaml.Unmarshal([]byte(document), secret); err != nil {
+			return nil, err
+		}
+		if secret.Kind != "Secret" {
+			log.Warnf("skipping the kubernetes resource: %s, kind: %s is not a secret", secret.Metadata.Name, secret.Kind)
+			continue
+		}
+		imported := &importedSecret{
+			name:      secret.Metadata.Name,
+			namespace: secret.Metadata.Namespace,
+			values:    make(map[string]interface{}, 0),
+		}
+		if imported.namespace == "" {
+			imported.namespace = r.namespace
+		}
+		for k, v := range secret.Data {
+			decoded, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return nil, fmt.Errorf("secret: %s, key: %s is not base64 encoded, error: %s", secret.Metadata.Name, k, err)
+			}
+			imported.values[k] = string(decoded)
+		}
+		for k, v := range secret.StringData {
+			imported.values[k] = v
+		}
+		list = append(list, imported)
+	}
+
+	return list, nil
+}
+
+// renderPath renders the vault path of a secret from the template
+func (r *importCommand) renderPath(secret *importedSecret) (string, error) {
+	tmpl, err := template.New("path").Funcs(template.FuncMap{
+		"name":      func() string { return secret.name },
+		"namespace": func() string { return secret.namespace },
+	}).Parse(r.path)
+	if err != nil {
+		return "", fmt.Errorf("invalid path template: %s, error: %s", r.path, err)
+	}
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, map[string]string{"name": secret.name, "namespace": secret.namespace}); err != nil {
+		return "", err
+	}
+
+	return strings.Trim(buf.String(), "/"), nil
+}
+
+// getCommand returns the command set
+func (r *importCommand) getCommand() cli.Command {
+	return cli.Command{
+		Name:  "import",
+		Usage: "imports secrets from dotenv, properties, json or kubernetes secret files into vault or the config",
+		Flags: []cli.Flag{
+			cli.StringSliceFlag{
+				Name:  "f, file",
+				Usage: "the path to a file to import, defaults to stdin (-)",
+			},
+			cli.StringFlag{
+				Name:        "F, format",
+				Usage:       "the format of the input, dotenv, properties, json or kube, detected from the extension if not set",
+				Destination: &r.format,
+			},
+			cli.StringFlag{
+				Name:        "P, path",
+				Usage:       "the template of the vault path, i.e. apps/{{namespace}}/{{name}}",
+				Value:       "secret/{{namespace}}/{{name}}",
+				Destination: &r.path,
+			},
+			cli.StringFlag{
+				Name:        "name",
+				Usage:       "the name of the secret, defaults to the file name or the kubernetes secret name",
+				Destination: &r.name,
+			},
+			cli.StringFlag{
+				Name:        "namespace",
+				Usage:       "the namespace of the secret, when not taken from the kubernetes secret",
+				Value:       "default",
+				Destination: &r.namespace,
+			},
+			cli.BoolFlag{
+				Name:        "w, write",
+				Usage:       "write the secrets to vault, rather than emitting the config",
+				Destination: &r.write,
+			},
+			cli.BoolFlag{
+				Name:        "replace",
+				Usage:       "replace the existing secrets in vault when writing, rather than merging the keys into them",
+				Destination: &r.replace,
+			},
+			cli.StringFlag{
+				Name:        "output-format",
+				Usage:       "the format of the emitted config, yaml, json or hcl",
+				Value:       "yaml",
+				Destination: &r.outputFormat,
+			},
+			cli.StringFlag{
+				Name:        "o, output",
+				Usage:       "the file to write the emitted config to, defaults to stdout",
+				Destination: &r.output,
+			},
+		},
+		Action: func(cx *cli.Context) {
+			executeCommand(cx, r.action)
+		},
+	}
+}
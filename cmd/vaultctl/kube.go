@@ -19,7 +19,9 @@ import (
 	"fmt"
 	"os"
 
+	vapi "github.com/UKHomeOffice/vaultctl/pkg/api"
 	"github.com/UKHomeOffice/vaultctl/pkg/utils"
+	"github.com/UKHomeOffice/vaultctl/pkg/vault"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/codegangsta/cli"
@@ -32,6 +34,8 @@ import (
 type kubeCmd struct {
 	// the kubernetes client
 	client *unversioned.Client
+	// the vault client, used to retrieve generated passwords
+	vault *vault.Client
 	// the kubeHost
 	kubeHost string
 	// the kubeconfig
@@ -57,9 +61,9 @@ func newKubeCommand() cli.Command {
 }
 
 type credential struct {
-	Method   string `yaml:"method"`
-	Username string `yaml:"username"`
-	Password string `yaml:"password"`
+	Method   string `yaml:"method" json:"method"`
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"password"`
 }
 
 func (r *kubeCmd) action(cx *cli.Context) error {
@@ -74,10 +78,10 @@ func (r *kubeCmd) action(cx *cli.Context) error {
 	}
 	r.client = client
 
-	return r.synchronize()
+	return r.synchronize(cx)
 }
 
-func (r *kubeCmd) synchronize() error {
+func (r *kubeCmd) synchronize(cx *cli.Context) error {
 	// step: get all the users from the config files and inject the
 	resources, err := parseConfigFiles(r.configFiles)
 	if err != nil {
@@ -89,8 +93,8 @@ func (r *kubeCmd) synchronize() error {
 
 	// step: iterate the users and inject into k8s
 	for _, u := range resources.users {
-		// step: skip any users which dont have namespaces or userpass
-		if u.Namespace == "" || u.UserPass == nil {
+		// step: skip any users which dont have namespaces or userpass, or are being removed
+		if u.Namespace == "" || u.UserPass == nil || u.IsAbsent() {
 			continue
 		}
 
@@ -100,6 +104,12 @@ func (r *kubeCmd) synchronize() error {
 			Username: u.UserPass.Username,
 			Password: u.UserPass.Password,
 		}
+		// step: if the password was generated, retrieve it from where it was stored
+		if u.UserPass.IsGenerated() {
+			if cred.Password, err = r.getGeneratedPassword(cx, u.UserPass); err != nil {
+				return err
+			}
+		}
 
 		// step: encode the yaml
 		content, err := utils.EncodeConfig(cred, "yml")
@@ -125,7 +135,7 @@ func (r *kubeCmd) synchronize() error {
 		}
 
 		if r.dryrun {
-			fmt.Fprint(os.Stdout, "%s", secret)
+			fmt.Fprintf(os.Stdout, "%v\n", secret)
 			continue
 		}
 
@@ -145,6 +155,38 @@ func (r *kubeCmd) synchronize() error {
 	return nil
 }
 
+// getGeneratedPassword retrieves a generated password from the file or vault path it was stored in
+func (r *kubeCmd) getGeneratedPassword(cx *cli.Context, user *vapi.UserPass) (string, error) {
+	if user.PasswordFile != "" && utils.IsFile(user.PasswordFile) {
+		cred := new(credential)
+		if err := utils.DecodeFile(user.PasswordFile, cred); err != nil {
+			return "", err
+		}
+		if cred.Password != "" {
+			return cred.Password, nil
+		}
+	}
+
+	if user.PasswordPath != "" {
+		if r.vault == nil {
+			client, err := getVaultClient(cx)
+			if err != nil {
+				return "", err
+			}
+			r.vault = client
+		}
+		values, err := r.vault.GetSecret(user.PasswordPath)
+		if err != nil {
+			return "", err
+		}
+		if password, found := values["password"]; found {
+			return fmt.Sprintf("%s", password), nil
+		}
+	}
+
+	return "", fmt.Errorf("unable to find the generated password for user: %s", user.Username)
+}
+
 // hasSecret check if the secret exists
 func (r *kubeCmd) hasSecret(name, namespace string) (bool, error) {
 	list, err := r.client.Secrets(namespace).List(labels.Everything(), fields.Everything())
@@ -0,0 +1,275 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/utils"
+	"github.com/UKHomeOffice/vaultctl/pkg/vault"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+)
+
+type renderCommand struct {
+	// the vault client
+	client *vault.Client
+	// the templates to render
+	templates []*renderTemplate
+	// the default file mode of the rendered files
+	mode string
+	// whether to keep watching vault for changes
+	watch bool
+	// the interval between checks when watching
+	interval time.Duration
+	// a command to run when the rendered files change
+	command string
+}
+
+// renderTemplate is a template and the file it's rendered to
+type renderTemplate struct {
+	// the path to the template
+	source string
+	// the path of the rendered file
+	destination string
+	// the file mode of the rendered file
+	mode os.FileMode
+}
+
+// newRenderCommand creates a new render command
+func newRenderCommand() cli.Command {
+	return new(renderCommand).getCommand()
+}
+
+// action renders the templates, optionally watching for changes
+func (r *renderCommand) action(cx *cli.Context) error {
+	templates, err := r.parseTemplates(cx.StringSlice("template"))
+	if err != nil {
+		return err
+	}
+	r.templates = templates
+	if r.watch && r.interval <= 0 {
+		return fmt.Errorf("the watch interval must be positive")
+	}
+
+	client, err := getVaultClient(cx)
+	if err != nil {
+		return err
+	}
+	r.client = client
+
+	for {
+		changed, err := r.renderTemplates()
+		if err != nil && r.watch {
+			log.Errorf("failed to render the templates, error: %s", err)
+		}
+		// step: the files which were written have changed, even if a later template failed
+		if changed && r.command != "" {
+			if cerr := r.runCommand(); cerr != nil {
+				if !r.watch && err == nil {
+					return cerr
+				}
+				log.Errorf("the command on change failed, error: %s", cerr)
+			}
+		}
+		if !r.watch {
+			return err
+		}
+		time.Sleep(r.interval)
+	}
+}
+
+// parseTemplates parses the template specs, source:destination[:mode]
+func (r *renderCommand) parseTemplates(specs []string) ([]*renderTemplate, error) {
+	if len(specs) <= 0 {
+		return nil, fmt.Errorf("you have not specified any templates")
+	}
+	var list []*renderTemplate
+	for _, x := range specs {
+		items := strings.Split(x, ":")
+		if len(items) < 2 || len(items) > 3 || items[0] == "" || items[1] == "" {
+			return nil, fmt.Errorf("invalid template: %s, must be source:destination[:mode]", x)
+		}
+		mode := r.mode
+		if len(items) == 3 {
+			mode = items[2]
+		}
+		perms, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file mode: %s for template: %s", mode, x)
+		}
+		if !utils.IsFile(items[0]) {
+			return nil, fmt.Errorf("the template: %s does not exist", items[0])
+		}
+		list = append(list, &renderTemplate{source: items[0], destination: items[1], mode: os.FileMode(perms)})
+	}
+
+	return list, nil
+}
+
+// renderTemplates renders all the templates, returning true if any of the files have changed
+func (r *renderCommand) renderTemplates() (bool, error) {
+	// step: the secrets are cached for the render so a secret is read once
+	cache := make(map[string]map[string]interface{}, 0)
+	funcs := template.FuncMap{
+		"secret": func(path, key string) (string, error) {
+			values, found := cache[path]
+			if !found {
+				var err error
+				if values, err = r.client.GetSecret(path); err != nil {
+					return "", err
+				}
+				if values == nil {
+					return "", fmt.Errorf("the secret: %s does not exist", path)
+				}
+				cache[path] = values
+			}
+			value, found := values[key]
+			if !found {
+				return "", fmt.Errorf("the secret: %s does not have the key: %s", path, key)
+			}
+			return fmt.Sprintf("%v", value), nil
+		},
+	}
+
+	changed := false
+	for _, x := range r.templates {
+		content, err := ioutil.ReadFile(x.source)
+		if err != nil {
+			return changed, err
+		}
+		tmpl, err := template.New(filepath.Base(x.source)).Funcs(funcs).Parse(string(content))
+		if err != nil {
+			return changed, fmt.Errorf("failed to parse the template: %s, error: %s", x.source, err)
+		}
+		buf := new(bytes.Buffer)
+		if err := tmpl.Execute(buf, nil); err != nil {
+			return changed, fmt.Errorf("failed to render the template: %s, error: %s", x.source, err)
+		}
+
+		// step: only write the file when the content has changed, though the mode may have
+		if existing, err := ioutil.ReadFile(x.destination); err == nil && bytes.Equal(existing, buf.Bytes()) {
+			if err := applyFileMode(x.destination, x.mode); err != nil {
+				return changed, err
+			}
+			continue
+		}
+		log.Infof("rendering the template: %s to: %s", x.source, x.destination)
+		if err := writeFileAtomic(x.destination, buf.Bytes(), x.mode); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// runCommand runs the command on change
+func (r *renderCommand) runCommand() error {
+	log.Infof("the rendered files have changed, running the command: %s", r.command)
+	cmd := exec.Command("sh", "-c", r.command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// applyFileMode changes the mode of an existing file if it differs
+func applyFileMode(filename string, mode os.FileMode) error {
+	stat, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	if stat.Mode().Perm() == mode.Perm() {
+		return nil
+	}
+	log.Infof("changing the mode of: %s from: %04o to: %04o", filename, stat.Mode().Perm(), mode.Perm())
+	if err := os.Chmod(filename, mode); err != nil {
+		return fmt.Errorf("failed to change the mode of: %s, error: %s", filename, err)
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes the content to a temporary file and renames it into place
+func writeFileAtomic(filename string, content []byte, mode os.FileMode) error {
+	temp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), mode); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), filename)
+}
+
+// getCommand returns the command set
+func (r *renderCommand) getCommand() cli.Command {
+	return cli.Command{
+		Name:  "render",
+		Usage: "renders go templates using the secrets in vault into local files",
+		Flags: []cli.Flag{
+			cli.StringSliceFlag{
+				Name:  "t, template",
+				Usage: "a template to render, source:destination[:mode], can be specified multiple times",
+			},
+			cli.StringFlag{
+				Name:        "m, mode",
+				Usage:       "the default file mode of the rendered files",
+				Value:       "0600",
+				Destination: &r.mode,
+			},
+			cli.BoolFlag{
+				Name:        "w, watch",
+				Usage:       "keep watching vault and render the templates when the values change",
+				Destination: &r.watch,
+			},
+			cli.DurationFlag{
+				Name:        "interval",
+				Usage:       "the interval between checking vault for changes when watching",
+				Value:       30 * time.Second,
+				Destination: &r.interval,
+			},
+			cli.StringFlag{
+				Name:        "exec",
+				Usage:       "a command to run when the rendered files change, i.e. 'systemctl reload nginx'",
+				Destination: &r.command,
+			},
+		},
+		Action: func(cx *cli.Context) {
+			executeCommand(cx, r.action)
+		},
+	}
+}
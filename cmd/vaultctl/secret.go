@@ -0,0 +1,277 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/api"
+	"github.com/UKHomeOffice/vaultctl/pkg/utils"
+	"github.com/UKHomeOffice/vaultctl/pkg/vault"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+)
+
+const (
+	// skipPolicy leaves an existing destination secret alone
+	skipPolicy = "skip"
+	// overwritePolicy replaces an existing destination secret
+	overwritePolicy = "overwrite"
+	// mergePolicy merges the source values into an existing destination secret
+	mergePolicy = "merge"
+)
+
+type secretCommand struct {
+	// the source vault client
+	source *vault.Client
+	// the destination vault client
+	destination *vault.Client
+	// whether the destination is a different vault
+	remote bool
+	// whether to copy the subtree under the path
+	recursive bool
+	// the policy applied to an existing destination secret
+	policy string
+	// whether to report the changes without making them
+	dryRun bool
+	// the address of the destination vault
+	toAddress string
+	// the token for the destination vault
+	toToken string
+	// the username for the destination vault
+	toUsername string
+	// the password for the destination vault
+	toPassword string
+	// the credentials file for the destination vault
+	toCredentials string
+}
+
+// newSecretCommand creates a new secret command
+func newSecretCommand() cli.Command {
+	return new(secretCommand).getCommand()
+}
+
+// copyAction copies the secrets from the source to the destination
+func (r *secretCommand) copyAction(cx *cli.Context) error {
+	return r.transfer(cx, false)
+}
+
+// moveAction moves the secrets from the source to the destination
+func (r *secretCommand) moveAction(cx *cli.Context) error {
+	return r.transfer(cx, true)
+}
+
+// transfer copies the secrets from the source to destination, deleting the source if moving
+func (r *secretCommand) transfer(cx *cli.Context, move bool) error {
+	if len(cx.Args()) != 2 {
+		return fmt.Errorf("you must specify a source and destination path")
+	}
+	src := strings.Trim(cx.Args()[0], "/")
+	dst := strings.Trim(cx.Args()[1], "/")
+	if src == "" || dst == "" {
+		return fmt.Errorf("the source and destination paths cannot be empty")
+	}
+	if !utils.ContainedIn(r.policy, []string{skipPolicy, overwritePolicy, mergePolicy}) {
+		return fmt.Errorf("unsupported policy: %s, must be skip, overwrite or merge", r.policy)
+	}
+
+	credentials := r.toToken != "" || r.toUsername != "" || r.toPassword != "" || r.toCredentials != ""
+	if r.toAddress == "" && credentials {
+		return fmt.Errorf("the destination authentication options require --to-addr")
+	}
+
+	// step: get the source and destination clients
+	client, err := getVaultClient(cx)
+	if err != nil {
+		return err
+	}
+	r.source = client
+	r.destination = client
+	// step: the source and destination paths are only independent on a different vault
+	r.remote = r.toAddress != "" && !utils.IsSameAddress(r.toAddress, cx.GlobalString("vault-addr"))
+	if r.remote || (r.toAddress != "" && credentials) {
+		r.destination, err = getTargetClient(cx, &api.Target{
+			Address:     r.toAddress,
+			Token:       r.toToken,
+			Username:    r.toUsername,
+			Password:    r.toPassword,
+			Credentials: r.toCredentials,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.copySecrets(src, dst, move)
+}
+
+// copySecrets copies the secret, or the subtree under it, from the source to the destination,
+// deleting the source secrets when moving
+func (r *secretCommand) copySecrets(src, dst string, move bool) error {
+	if !r.remote && (src == dst || (r.recursive && strings.HasPrefix(dst+"/", src+"/"))) {
+		return fmt.Errorf("the destination: %s cannot be the source or within it", dst)
+	}
+
+	// step: get the secrets to copy
+	secrets := make(map[string]map[string]interface{}, 0)
+	if r.recursive {
+		var err error
+		if secrets, err = r.source.ListSecrets(src); err != nil {
+			return err
+		}
+	} else {
+		values, err := r.source.GetSecret(src)
+		if err != nil {
+			return err
+		}
+		if values == nil {
+			return fmt.Errorf("the secret: %s does not exist", src)
+		}
+		secrets[src] = values
+	}
+	if len(secrets) <= 0 {
+		return fmt.Errorf("no secrets found under: %s", src)
+	}
+	var paths []string
+	for k := range secrets {
+		paths = append(paths, k)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		target := dst + strings.TrimPrefix(path, src)
+		copied, err := r.copySecret(path, target, secrets[path])
+		if err != nil {
+			return err
+		}
+		if !move || !copied {
+			continue
+		}
+		log.Infof("[secret: %s] deleting the source secret, dry-run: %t", path, r.dryRun)
+		if r.dryRun {
+			continue
+		}
+		if err := r.source.DeleteSecret(path); err != nil {
+			return fmt.Errorf("failed to delete the secret: %s, error: %s", path, err)
+		}
+	}
+
+	return nil
+}
+
+// copySecret copies the values of a secret to the destination applying the policy, returning true
+// if the destination holds the source values afterwards
+func (r *secretCommand) copySecret(src, dst string, values map[string]interface{}) (bool, error) {
+	current, err := r.destination.GetSecret(dst)
+	if err != nil {
+		return false, err
+	}
+
+	secret := &api.Secret{Path: dst, Values: values}
+	if current != nil {
+		switch r.policy {
+		case skipPolicy:
+			log.Warnf("[secret: %s] skipping, the destination: %s already exists", src, dst)
+			return false, nil
+		case mergePolicy:
+			secret.Mode = api.MergeMode
+			secret.Values = secret.Reconcile(values, current)
+		}
+	}
+	diff := secret.Diff(current)
+	log.Infof("[secret: %s] copying to: %s, added: [%s], changed: [%s], removed: [%s], dry-run: %t", src, dst,
+		strings.Join(diff.Added, ","), strings.Join(diff.Changed, ","), strings.Join(diff.Removed, ","), r.dryRun)
+	if r.dryRun || !diff.HasChanged() {
+		return true, nil
+	}
+
+	return true, r.destination.AddSecret(secret)
+}
+
+// getCommand returns the command set
+func (r *secretCommand) getCommand() cli.Command {
+	flags := []cli.Flag{
+		cli.BoolFlag{
+			Name:        "r, recursive",
+			Usage:       "copy the subtree of secrets under the source path",
+			Destination: &r.recursive,
+		},
+		cli.StringFlag{
+			Name:        "policy",
+			Usage:       "the policy when the destination secret exists, skip, overwrite or merge",
+			Value:       skipPolicy,
+			Destination: &r.policy,
+		},
+		cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "report the changes without making them",
+			Destination: &r.dryRun,
+		},
+		cli.StringFlag{
+			Name:        "to-addr",
+			Usage:       "the url address of the destination vault, defaults to the source vault",
+			Destination: &r.toAddress,
+		},
+		cli.StringFlag{
+			Name:        "to-token",
+			Usage:       "a vault token used to authenticate to the destination vault",
+			Destination: &r.toToken,
+		},
+		cli.StringFlag{
+			Name:        "to-username",
+			Usage:       "the vault username used to authenticate to the destination vault",
+			Destination: &r.toUsername,
+		},
+		cli.StringFlag{
+			Name:        "to-password",
+			Usage:       "the vault password used to authenticate to the destination vault",
+			Destination: &r.toPassword,
+		},
+		cli.StringFlag{
+			Name:        "to-credentials",
+			Usage:       "the path to a file (json|yaml) containing the credentials for the destination vault",
+			Destination: &r.toCredentials,
+		},
+	}
+
+	return cli.Command{
+		Name:  "secret",
+		Usage: "copies and moves generic secrets between paths, mounts and vaults",
+		Subcommands: []cli.Command{
+			{
+				Name:      "cp",
+				Usage:     "copies a secret, or with --recursive a subtree of secrets, to a destination path",
+				ArgsUsage: "SOURCE DESTINATION",
+				Flags:     flags,
+				Action: func(cx *cli.Context) {
+					executeCommand(cx, r.copyAction)
+				},
+			},
+			{
+				Name:      "mv",
+				Usage:     "moves a secret, or with --recursive a subtree of secrets, to a destination path",
+				ArgsUsage: "SOURCE DESTINATION",
+				Flags:     flags,
+				Action: func(cx *cli.Context) {
+					executeCommand(cx, r.moveAction)
+				},
+			},
+		},
+	}
+}
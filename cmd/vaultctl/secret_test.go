@@ -0,0 +1,175 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/vault"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVault is a minimal generic secret backend
+type fakeVault struct {
+	sync.Mutex
+	secrets map[string]map[string]interface{}
+}
+
+func (r *fakeVault) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+	path := strings.TrimPrefix(req.URL.Path, "/v1/")
+	switch req.Method {
+	case "GET":
+		if req.URL.Query().Get("list") != "" {
+			var keys []string
+			seen := make(map[string]bool, 0)
+			for k := range r.secrets {
+				if !strings.HasPrefix(k, path+"/") {
+					continue
+				}
+				key := strings.TrimPrefix(k, path+"/")
+				if i := strings.Index(key, "/"); i >= 0 {
+					key = key[:i+1]
+				}
+				if !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+			}
+			if len(keys) <= 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"keys": keys}})
+			return
+		}
+		values, found := r.secrets[path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": values})
+	case "PUT":
+		values := make(map[string]interface{})
+		json.NewDecoder(req.Body).Decode(&values)
+		r.secrets[path] = values
+		w.WriteHeader(http.StatusNoContent)
+	case "DELETE":
+		delete(r.secrets, path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func newTestSecretCommand(t *testing.T, secrets map[string]map[string]interface{}) (*secretCommand, *fakeVault, func()) {
+	fake := &fakeVault{secrets: secrets}
+	server := httptest.NewServer(fake)
+	client, err := vault.New(server.URL, "", "", "", "token")
+	if !assert.NoError(t, err) {
+		server.Close()
+		t.FailNow()
+	}
+
+	return &secretCommand{source: client, destination: client}, fake, server.Close
+}
+
+func TestCopySecretMerge(t *testing.T) {
+	cmd, fake, closer := newTestSecretCommand(t, map[string]map[string]interface{}{
+		"secret/src": {"username": "admin", "password": "changed"},
+		"secret/dst": {"password": "old", "token": "keep"},
+	})
+	defer closer()
+	cmd.policy = mergePolicy
+
+	copied, err := cmd.copySecret("secret/src", "secret/dst", map[string]interface{}{"username": "admin", "password": "changed"})
+	assert.NoError(t, err)
+	assert.True(t, copied)
+	assert.Equal(t, map[string]interface{}{"username": "admin", "password": "changed", "token": "keep"},
+		fake.secrets["secret/dst"])
+}
+
+func TestMoveSecrets(t *testing.T) {
+	tests := []struct {
+		Policy   string
+		Moved    bool
+		Expected map[string]interface{}
+	}{
+		{
+			Policy:   mergePolicy,
+			Moved:    true,
+			Expected: map[string]interface{}{"password": "changed", "token": "keep"},
+		},
+		{
+			Policy:   overwritePolicy,
+			Moved:    true,
+			Expected: map[string]interface{}{"password": "changed"},
+		},
+		{
+			Policy:   skipPolicy,
+			Expected: map[string]interface{}{"password": "old", "token": "keep"},
+		},
+	}
+	for i, c := range tests {
+		cmd, fake, closer := newTestSecretCommand(t, map[string]map[string]interface{}{
+			"secret/src": {"password": "changed"},
+			"secret/dst": {"password": "old", "token": "keep"},
+		})
+		cmd.policy = c.Policy
+		err := cmd.copySecrets("secret/src", "secret/dst", true)
+		closer()
+		if !assert.NoError(t, err, "case %d should have not errored", i) {
+			continue
+		}
+		_, found := fake.secrets["secret/src"]
+		assert.Equal(t, !c.Moved, found, "case %d source not as expected", i)
+		assert.Equal(t, c.Expected, fake.secrets["secret/dst"], "case %d destination not as expected", i)
+	}
+}
+
+func TestCopySecretsGuards(t *testing.T) {
+	cmd, _, closer := newTestSecretCommand(t, map[string]map[string]interface{}{
+		"secret/src": {"password": "changed"},
+	})
+	defer closer()
+	cmd.policy = overwritePolicy
+	cmd.recursive = true
+	assert.Error(t, cmd.copySecrets("secret/src", "secret/src", false))
+	assert.Error(t, cmd.copySecrets("secret/src", "secret/src/nested", false))
+}
+
+func TestMoveSecretsRecursive(t *testing.T) {
+	cmd, fake, closer := newTestSecretCommand(t, map[string]map[string]interface{}{
+		"secret/apps/a":        {"key": "a"},
+		"secret/apps/nested/b": {"key": "b"},
+		"secret/other":         {"key": "other"},
+	})
+	defer closer()
+	cmd.policy = skipPolicy
+	cmd.recursive = true
+
+	assert.NoError(t, cmd.copySecrets("secret/apps", "secret/moved", true))
+	assert.Equal(t, map[string]map[string]interface{}{
+		"secret/moved/a":        {"key": "a"},
+		"secret/moved/nested/b": {"key": "b"},
+		"secret/other":          {"key": "other"},
+	}, fake.secrets)
+}
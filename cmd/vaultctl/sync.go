@@ -16,9 +16,13 @@ limitations under the License.
 package main
 
 import (
+	"encoding/json"
 	"fmt"
-	"net/http"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/UKHomeOffice/vaultctl/pkg/api"
@@ -38,6 +42,8 @@ type syncCommand struct {
 	configFiles []string
 	// whether to perform a full sync
 	fullsync bool
+	// whether a full sync also removes the users no longer referenced
+	pruneUsers bool
 	// the vault client
 	client *vault.Client
 	// whether to skip applying the auths to vault
@@ -54,6 +60,42 @@ type syncCommand struct {
 	delete bool
 	// configExtension
 	configExtension string
+	// a list of targets to synchronize
+	targets []string
+	// whether to synchronize the targets in parallel
+	parallel bool
+	// the logger for the target being synchronized
+	log *log.Entry
+	// whether to permit backend and auth types unknown to vaultctl
+	allowUnknownTypes bool
+	// the types supported by the vault server, nil if the server has no catalog
+	catalog *vault.Catalog
+	// whether the catalog has been retrieved
+	catalogLoaded bool
+	// the vault path of the vaultctl state record
+	statePath string
+	// the state record for the target
+	state *vault.State
+	// a list of oneshot attributes to execute again
+	rerunOneshots []string
+	// whether to record the unrecorded oneshots on already mounted backends as executed
+	seedOneshots bool
+	// the variables captured from the outputs of the attributes
+	variables map[string]string
+	// whether to show the hashes of the changed secret values rather than masking them
+	showHashes bool
+	// a list of secret paths or path:key selectors whose generated values are regenerated
+	rotateSecrets []string
+}
+
+// syncResult is the outcome of synchronizing a target
+type syncResult struct {
+	// the name of the target
+	target string
+	// the error if any
+	err error
+	// the time taken
+	duration time.Duration
 }
 
 // newSyncCommand create a new sync command
@@ -67,30 +109,175 @@ func (r *syncCommand) action(cx *cli.Context) error {
 	if err := r.validateAction(cx); err != nil {
 		return err
 	}
-	// step: get a vault client
-	client, err := getVaultClient(cx)
-	if err != nil {
-		return err
-	}
-	r.client = client
-
 	// step: parse the configuration files
+	var err error
 	r.resources, err = parseConfigFiles(r.configFiles)
 	if err != nil {
 		return err
 	}
-	// step: synchronize the elements
-	if err := r.synchronize(); err != nil {
+	// step: validate the resources before touching any of the targets
+	if err := validateResources(r.resources, r.validationOptions()); err != nil {
+		return err
+	}
+	// step: get the targets we are synchronizing
+	targets, err := r.getTargets()
+	if err != nil {
 		return err
 	}
+	// step: synchronize the targets
+	results := make([]*syncResult, len(targets))
+	if r.parallel {
+		var wg sync.WaitGroup
+		for i, x := range targets {
+			wg.Add(1)
+			go func(i int, x *api.Target) {
+				defer wg.Done()
+				results[i] = r.synchronizeTarget(cx, x)
+			}(i, x)
+		}
+		wg.Wait()
+	} else {
+		for i, x := range targets {
+			results[i] = r.synchronizeTarget(cx, x)
+		}
+	}
+
+	// step: report the results for each target
+	var failed []string
+	for _, x := range results {
+		if x.err != nil {
+			log.Errorf("[target: %s] synchronization failed, time took: %s, error: %s", x.target, x.duration.String(), x.err)
+			failed = append(failed, x.target)
+			continue
+		}
+		log.Infof("[target: %s] synchronization complete, time took: %s", x.target, x.duration.String())
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to synchronize the targets: %s", strings.Join(failed, ","))
+	}
+
+	log.Infof("synchronization complete, targets: %d, time took: %s", len(targets), time.Now().Sub(startTime).String())
+
+	return nil
+}
+
+// getTargets retrieves the targets to synchronize, defaulting to the vault specified by the global options
+func (r *syncCommand) getTargets() ([]*api.Target, error) {
+	if len(r.resources.targets) <= 0 {
+		if len(r.targets) > 0 {
+			return nil, fmt.Errorf("you have selected targets but none are defined in the configuration")
+		}
+		return []*api.Target{{Name: defaultTarget}}, nil
+	}
 
-	log.Infof("synchronization complete, time took: %s", time.Now().Sub(startTime).String())
+	var list []*api.Target
+	names := make(map[string]bool, 0)
+	for _, x := range r.resources.targets {
+		if err := x.IsValid(); err != nil {
+			return nil, err
+		}
+		if names[x.Name] {
+			return nil, fmt.Errorf("the target: %s has been defined more than once", x.Name)
+		}
+		names[x.Name] = true
+
+		if len(r.targets) > 0 && !utils.ContainedIn(x.Name, r.targets) {
+			continue
+		}
+		list = append(list, x)
+	}
+	// step: ensure all the selected targets exist
+	for _, x := range r.targets {
+		if !names[x] {
+			return nil, fmt.Errorf("the target: %s is not defined in the configuration", x)
+		}
+	}
+	if len(list) > 1 {
+		if err := r.checkLocalFiles(); err != nil {
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
+
+// checkLocalFiles ensures nothing is written to a local file when synchronizing multiple targets, as
+// each target would overwrite the file with it's own content
+func (r *syncCommand) checkLocalFiles() error {
+	// step: a password file holds a single credential, so cannot be shared by the targets
+	if !r.skipUsers {
+		for _, x := range r.resources.users {
+			if x.UserPass != nil && x.UserPass.IsGenerated() && x.UserPass.PasswordFile != "" && !x.IsAbsent() {
+				return fmt.Errorf("the user: %s has a password-file which cannot be used with multiple targets, use a password-path", x.Username())
+			}
+		}
+	}
+	// step: the outputs of the attributes are specific to a target
+	var attrs []*api.Attributes
+	if !r.skipAuths {
+		for _, x := range r.resources.auths {
+			if !x.IsAbsent() {
+				attrs = append(attrs, x.Attrs...)
+			}
+		}
+	}
+	if !r.skipBackends {
+		for _, x := range r.resources.backends {
+			if x.IsAbsent() {
+				continue
+			}
+			// step: the ca chain is specific to a target
+			if x.PKI != nil && x.PKI.ExportFile != "" {
+				return fmt.Errorf("the backend: %s has an export-file: %s which cannot be used with multiple targets, use an export-path",
+					x.Path, x.PKI.ExportFile)
+			}
+			attrs = append(attrs, x.Attrs...)
+		}
+	}
+	for _, x := range attrs {
+		outputs, err := x.GetOutputs()
+		if err != nil {
+			return err
+		}
+		for _, o := range outputs {
+			if o.File != "" {
+				return fmt.Errorf("the attribute: %s has an output file: %s which cannot be used with multiple targets, use a vault-path", x.URI(), o.File)
+			}
+		}
+	}
 
 	return nil
 }
 
+// synchronizeTarget synchronizes the resources against a single target
+func (r *syncCommand) synchronizeTarget(cx *cli.Context, target *api.Target) *syncResult {
+	startTime := time.Now()
+	result := &syncResult{target: target.Name}
+
+	// step: create a copy of the command for the target
+	s := *r
+	s.log = log.WithField("target", target.Name)
+
+	// step: get a vault client for the target
+	var err error
+	if target.Name == defaultTarget && target.Address == "" {
+		s.client, err = getVaultClient(cx)
+	} else {
+		s.log.Infof("synchronizing the target, address: %s", target.Address)
+		s.client, err = getTargetClient(cx, target)
+	}
+	if err == nil {
+		err = s.synchronize()
+	}
+	result.err = err
+	result.duration = time.Now().Sub(startTime)
+
+	return result
+}
+
 // synchronize process the items and sync them
 func (r *syncCommand) synchronize() error {
+	r.variables = make(map[string]string, 0)
 	if !r.skipAuths {
 		if err := r.applyAuths(r.resources.auths); err != nil {
 			return err
@@ -107,6 +294,15 @@ func (r *syncCommand) synchronize() error {
 		}
 	}
 	if !r.skipBackends {
+		state, err := r.client.LoadState(r.statePath)
+		if err != nil {
+			return err
+		}
+		r.state = state
+		if err := r.checkOneshotState(r.resources.backends); err != nil {
+			return err
+		}
+
 		if err := r.applyBackends(r.resources.backends); err != nil {
 			return err
 		}
@@ -122,16 +318,15 @@ func (r *syncCommand) synchronize() error {
 
 // applyAuths applies the auth backends
 func (r *syncCommand) applyAuths(auths []*api.Auth) error {
-	log.Infof("%s", color.GreenString("-> synchronizing the auth backends, %d backends", len(auths)))
+	r.log.Infof("%s", color.GreenString("-> synchronizing the auth backends, %d backends", len(auths)))
 
 	var list []string
 
 	for _, x := range auths {
 		// step: check the backend is valid
-		if err := x.IsValid(); err != nil {
+		if err := x.IsValidWith(r.validationOptions()); err != nil {
 			return err
 		}
-		list = append(list, x.Path)
 
 		// step: check the backend is mounted
 		mounted, err := r.client.Client().Sys().ListAuth()
@@ -139,14 +334,31 @@ func (r *syncCommand) applyAuths(auths []*api.Auth) error {
 			return err
 		}
 
+		// step: are we removing the backend?
+		if x.IsAbsent() {
+			if _, found := mounted[x.Path+"/"]; !found {
+				r.log.Infof("[auth: %s] state absent, the backend is not mounted", x.Path)
+				continue
+			}
+			r.log.Infof("[auth: %s] state absent, disabling the backend", x.Path)
+			if err := r.client.Client().Sys().DisableAuth(x.Path); err != nil {
+				return fmt.Errorf("failed to disable the auth: %s, error: %s", x.Path, err)
+			}
+			continue
+		}
+		list = append(list, x.Path)
+
 		// step: if not mounted? attempt to mount
 		if _, found := mounted[x.Path+"/"]; !found {
-			log.Infof("[auth: %s] type: %s is not mounted, attempting to mount now", x.Path, x.Type)
+			r.log.Infof("[auth: %s] type: %s is not mounted, attempting to mount now", x.Path, x.Type)
+			if err := r.checkServerSupports("auth", x.Type); err != nil {
+				return err
+			}
 			if err := r.client.Client().Sys().EnableAuth(x.Path, x.Type, x.Description); err != nil {
 				return err
 			}
 		} else {
-			log.Infof("[auth: %s] already create, skipping to configuration", x.Path)
+			r.log.Infof("[auth: %s] already create, skipping to configuration", x.Path)
 		}
 
 		// step: config the backend
@@ -157,13 +369,7 @@ func (r *syncCommand) applyAuths(auths []*api.Auth) error {
 			if err := c.IsValid(); err != nil {
 				return fmt.Errorf("the attribute for auth backend: %s invalid, error: %s", x.Path, err)
 			}
-			log.Infof("[auth->config: %s] applying configuration to auth", uri)
-
-			resp, err := r.client.Request("POST", uri, &c)
-			if err != nil {
-				return err
-			}
-			if resp.StatusCode != http.StatusNoContent {
+			if err := r.applyAttributes("auth->config", "POST", uri, c); err != nil {
 				return err
 			}
 		}
@@ -181,7 +387,7 @@ func (r *syncCommand) applyAuths(auths []*api.Auth) error {
 			}
 
 			if !utils.ContainedIn(strings.TrimSuffix(name, "/"), list) {
-				log.Warnf("[auth: %s] is no longer referenced, delete: %t", name, r.delete)
+				r.log.Warnf("[auth: %s] is no longer referenced, delete: %t", name, r.delete)
 				if !r.delete {
 					continue
 				}
@@ -197,16 +403,23 @@ func (r *syncCommand) applyAuths(auths []*api.Auth) error {
 
 // applyPolicies applies the policies to a vault instance
 func (r *syncCommand) applyPolicies(policies []*api.Policy) error {
-	log.Infof("%s", color.GreenString("-> synchronizing the vault policies, %d policies", len(policies)))
+	r.log.Infof("%s", color.GreenString("-> synchronizing the vault policies, %d policies", len(policies)))
 
 	var list []string
 
 	for _, p := range policies {
+		// step: are we removing the policy?
+		if p.IsAbsent() {
+			if err := r.removePolicy(p); err != nil {
+				return err
+			}
+			continue
+		}
 		if err := r.client.SetPolicy(p.Name, p.Policy); err != nil {
 			return err
 		}
 		list = append(list, p.Name)
-		log.Infof("[policy: %s] successfully applied the policy", p.Name)
+		r.log.Infof("[policy: %s] successfully applied the policy", p.Name)
 	}
 
 	if r.fullsync {
@@ -222,7 +435,7 @@ func (r *syncCommand) applyPolicies(policies []*api.Policy) error {
 			}
 			// step: check the policy is referenced still
 			if !utils.ContainedIn(x, list) {
-				log.Warningf("[policy: %s] no longer referenced in config, delete: %t", x, r.delete)
+				r.log.Warningf("[policy: %s] no longer referenced in config, delete: %t", x, r.delete)
 				if !r.delete {
 					continue
 				}
@@ -236,21 +449,63 @@ func (r *syncCommand) applyPolicies(policies []*api.Policy) error {
 	return nil
 }
 
+// removePolicy deletes a policy marked as absent if it exists
+func (r *syncCommand) removePolicy(policy *api.Policy) error {
+	current, err := r.client.Policies()
+	if err != nil {
+		return err
+	}
+	if !current[policy.Name] {
+		r.log.Infof("[policy: %s] state absent, the policy does not exist", policy.Name)
+		return nil
+	}
+	r.log.Infof("[policy: %s] state absent, deleting the policy", policy.Name)
+
+	return r.client.Client().Sys().DeletePolicy(policy.Name)
+}
+
 // applyUsers synchronizes the users with vault
 func (r *syncCommand) applyUsers(users []*api.User) error {
-	log.Infof("%s", color.GreenString("-> synchronizing the vault users, users: %d", len(users)))
+	r.log.Infof("%s", color.GreenString("-> synchronizing the vault users, users: %d", len(users)))
+
+	var list []string
 
 	for _, x := range users {
 		// step: validate the user
 		if err := x.IsValid(); err != nil {
 			return err
 		}
-		path := x.Path
-		if path == "" {
-			path = "default"
+
+		// step: are we removing the user?
+		if x.IsAbsent() {
+			removed, err := r.client.RemoveUser(x)
+			if err != nil {
+				return fmt.Errorf("failed to remove the user: %s/%s, error: %s", x.GetPath(), x.Username(), err)
+			}
+			r.log.Infof("[user: %s/%s] state absent, removed: %t", x.GetPath(), x.Username(), removed)
+			continue
 		}
+		list = append(list, x.GetURI())
 
-		log.Infof("[user: %s/%s] ensuring user, policies: %s", path, x.Username(), x.GetPolicies())
+		r.log.Infof("[user: %s/%s] ensuring %s, policies: %s", x.GetPath(), x.Username(), x.GetType(), x.GetPolicies())
+
+		// step: are we generating the password for the user?
+		if x.UserPass != nil && x.UserPass.IsGenerated() {
+			if err := r.applyGeneratedUser(x); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// step: tokens are looked up and only created, renewed or recreated when required
+		if x.UserToken != nil {
+			action, err := r.client.EnsureToken(x)
+			if err != nil {
+				return err
+			}
+			r.log.Infof("[user: %s/%s] token %s", x.GetPath(), x.Username(), action)
+			continue
+		}
 
 		// step: attempt to add the user
 		if err := r.client.AddUser(x); err != nil {
@@ -258,19 +513,113 @@ func (r *syncCommand) applyUsers(users []*api.User) error {
 		}
 	}
 
+	if r.fullsync && r.pruneUsers {
+		mounted, err := r.client.Client().Sys().ListAuth()
+		if err != nil {
+			return err
+		}
+		// step: iterate the auth backends and remove any users no longer referenced
+		for name, mount := range mounted {
+			path := strings.TrimSuffix(name, "/")
+			for _, collection := range api.GetAuthCollections(mount.Type) {
+				names, err := r.client.ListUsers(path, collection)
+				if err != nil {
+					return fmt.Errorf("failed to list the users in: %s/%s, error: %s", path, collection, err)
+				}
+				for _, username := range names {
+					uri := fmt.Sprintf("auth/%s/%s/%s", path, collection, username)
+					if utils.ContainedIn(uri, list) {
+						continue
+					}
+					r.log.Warnf("[user: %s] no longer referenced, delete: %t", uri, r.delete)
+					if !r.delete {
+						continue
+					}
+					if err := r.client.DeleteUser(path, collection, username); err != nil {
+						return fmt.Errorf("failed to delete the user: %s, error: %s", uri, err)
+					}
+				}
+			}
+		}
+	}
+
 	return nil
 }
 
+// applyGeneratedUser creates a userpass user with a generated password, the password is only
+// set on creation, afterwards only the policies are updated
+func (r *syncCommand) applyGeneratedUser(user *api.User) error {
+	exists, err := r.client.HasUser(user)
+	if err != nil {
+		return err
+	}
+	if exists {
+		r.log.Infof("[user: %s/%s] already exists, updating the policies only", user.GetPath(), user.Username())
+		return r.client.AddUser(user)
+	}
+
+	password, err := utils.RandomString(generatedPasswordLength, utils.PasswordCharset)
+	if err != nil {
+		return err
+	}
+	cred := &credential{
+		Method:   "userpass",
+		Username: user.UserPass.Username,
+		Password: password,
+	}
+
+	// step: store the credential before creating the user, so it can never be lost
+	if user.UserPass.PasswordPath != "" {
+		r.log.Infof("[user: %s/%s] storing the generated password in: %s", user.GetPath(), user.Username(), user.UserPass.PasswordPath)
+		if err := r.client.AddSecret(&api.Secret{
+			Path: user.UserPass.PasswordPath,
+			Values: map[string]interface{}{
+				"method":   cred.Method,
+				"username": cred.Username,
+				"password": cred.Password,
+			},
+		}); err != nil {
+			return fmt.Errorf("unable to store the generated password for user: %s, error: %s", user.Username(), err)
+		}
+	}
+	if user.UserPass.PasswordFile != "" {
+		r.log.Infof("[user: %s/%s] writing the generated password to: %s", user.GetPath(), user.Username(), user.UserPass.PasswordFile)
+		content, err := utils.EncodeConfig(cred, strings.TrimPrefix(filepath.Ext(user.UserPass.PasswordFile), "."))
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(user.UserPass.PasswordFile, content, 0600); err != nil {
+			return fmt.Errorf("unable to write the generated password for user: %s, error: %s", user.Username(), err)
+		}
+	}
+
+	// step: create the user with the generated password
+	u := *user
+	u.UserPass = &api.UserPass{
+		Username: user.UserPass.Username,
+		Password: password,
+	}
+
+	return r.client.AddUser(&u)
+}
+
 // syncBackends synchronizes the backend's with vault
 func (r *syncCommand) applyBackends(backends []*api.Backend) error {
-	log.Infof("%s", color.GreenString("-> synchronizing the backends, backend: %d", len(backends)))
+	r.log.Infof("%s", color.GreenString("-> synchronizing the backends, backend: %d", len(backends)))
 
 	var list []string
 
 	for _, backend := range backends {
-		if err := backend.IsValid(); err != nil {
+		if err := backend.IsValidWith(r.validationOptions()); err != nil {
 			return err
 		}
+		// step: are we removing the backend?
+		if backend.IsAbsent() {
+			if err := r.removeBackend(backend); err != nil {
+				return err
+			}
+			continue
+		}
 		// step: add to the list
 		list = append(list, backend.GetPath())
 
@@ -283,10 +632,39 @@ func (r *syncCommand) applyBackends(backends []*api.Backend) error {
 			return err
 		}
 
+		// step: are we moving the backend from a previous path?
+		if backend.MovedFrom != "" {
+			from := backend.GetMovedFrom()
+			// step: never prune the previous path
+			list = append(list, from)
+
+			_, fromFound := mounted[from+"/"]
+			_, toFound := mounted[path+"/"]
+			switch {
+			case fromFound && toFound:
+				return fmt.Errorf("backend: %s, unable to move from: %s as both paths are mounted", path, from)
+			case fromFound:
+				r.log.Infof("[backend: %s] moving the backend from: %s", path, from)
+				if err := r.client.Client().Sys().Remount(from, path); err != nil {
+					return fmt.Errorf("failed to move the backend: %s to: %s, error: %s", from, path, err)
+				}
+				if mounted, err = r.client.Mounts(); err != nil {
+					return err
+				}
+			case toFound:
+				r.log.Warnf("[backend: %s] the move from: %s has been applied, the moved_from field can be removed", path, from)
+			default:
+				r.log.Warnf("[backend: %s] neither the path nor the previous path: %s are mounted, nothing to move, creating an empty backend", path, from)
+			}
+		}
+
 		// step: check if the backend if already mounted
-		_, found := mounted[backend.GetPath()+"/"]
+		mount, found := mounted[backend.GetPath()+"/"]
 		if !found {
-			log.Infof("[backend: %s] creating backend", path)
+			r.log.Infof("[backend: %s] creating backend", path)
+			if err := r.checkServerSupports("backend", backend.Type); err != nil {
+				return err
+			}
 			if err := r.client.Client().Sys().Mount(path, &v.MountInput{
 				Type:        backend.Type,
 				Description: backend.Description,
@@ -298,7 +676,10 @@ func (r *syncCommand) applyBackends(backends []*api.Backend) error {
 				return err
 			}
 		} else {
-			log.Infof("[backend: %s]: already exist, moving to configuration", path)
+			r.log.Infof("[backend: %s]: already exist, moving to configuration", path)
+			if err := r.tuneBackend(backend, mount); err != nil {
+				return err
+			}
 		}
 
 		// step: apply the configuration
@@ -307,23 +688,40 @@ func (r *syncCommand) applyBackends(backends []*api.Backend) error {
 			uri := c.GetPath(path)
 
 			// step: check if a once type setting?
-			if found && c.IsOneshot() {
-				log.Infof("[backend:%s] skipping the config, as it's a oneshot setting", uri)
+			if c.IsOneshot() {
+				previous := ""
+				if backend.MovedFrom != "" {
+					previous = c.GetPath(backend.GetMovedFrom())
+				}
+				if err := r.applyOneshot(uri, previous, c, found); err != nil {
+					return err
+				}
 				continue
 			}
 
-			log.Infof("[backend->config: %s] applying configuration for backend", uri)
-
-			resp, err := r.client.Request("PUT", uri, &c)
-			if err != nil {
+			if err := r.applyAttributes("backend->config", "PUT", uri, c); err != nil {
 				return err
 			}
-			if resp.StatusCode != http.StatusNoContent {
+		}
+
+		// step: reconcile any transit keys
+		if err := r.applyTransitKeys(backend); err != nil {
+			return err
+		}
+
+		// step: prune any items in the managed collections no longer referenced
+		if r.fullsync {
+			if err := r.pruneManaged(backend); err != nil {
 				return err
 			}
 		}
 	}
 
+	// step: bootstrap any pki certificate authorities
+	if err := r.applyPKI(backends); err != nil {
+		return err
+	}
+
 	if r.fullsync {
 		mounted, err := r.client.Client().Sys().ListMounts()
 		if err != nil {
@@ -337,7 +735,7 @@ func (r *syncCommand) applyBackends(backends []*api.Backend) error {
 				continue
 			}
 			if !utils.ContainedIn(strings.TrimSuffix(name, "/"), list) {
-				log.Warnf("[backend: %s] no longer referenced, delete: %t", name, r.delete)
+				r.log.Warnf("[backend: %s] no longer referenced, delete: %t", name, r.delete)
 				if !r.delete {
 					continue
 				}
@@ -351,9 +749,497 @@ func (r *syncCommand) applyBackends(backends []*api.Backend) error {
 	return nil
 }
 
+// applyTransitKeys creates, configures and rotates the keys declared on a transit backend
+func (r *syncCommand) applyTransitKeys(backend *api.Backend) error {
+	path := backend.GetPath()
+	for _, x := range backend.Keys {
+		current, err := r.client.GetTransitKey(path, x.Name)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve the transit key: %s/keys/%s, error: %s", path, x.Name, err)
+		}
+		// step: create the key if missing
+		if current == nil {
+			r.log.Infof("[transit: %s] creating the key: %s", path, x.Name)
+			if err := r.client.CreateTransitKey(path, x); err != nil {
+				return fmt.Errorf("failed to create the transit key: %s/keys/%s, error: %s", path, x.Name, err)
+			}
+			if current, err = r.client.GetTransitKey(path, x.Name); err != nil {
+				return err
+			}
+			if current == nil {
+				return fmt.Errorf("the transit key: %s/keys/%s was not found after creation", path, x.Name)
+			}
+		}
+		if current.Derived != x.Derived {
+			r.log.Warnf("[transit: %s] the key: %s derived is %t, it cannot be changed after creation", path, x.Name, current.Derived)
+		}
+
+		// step: reconcile the key configuration
+		if current.DeletionAllowed != x.DeletionAllowed ||
+			(x.MinDecryptionVersion > 0 && current.MinDecryptionVersion != x.MinDecryptionVersion) {
+			r.log.Infof("[transit: %s] updating the configuration of the key: %s", path, x.Name)
+			if err := r.client.ConfigureTransitKey(path, x); err != nil {
+				return fmt.Errorf("failed to configure the transit key: %s/keys/%s, error: %s", path, x.Name, err)
+			}
+		}
+
+		// step: rotate the key if the latest version has expired
+		if x.RotateEvery > 0 && !current.Created.IsZero() && time.Since(current.Created) > x.RotateEvery {
+			r.log.Infof("[transit: %s] rotating the key: %s, version: %d is older than %s",
+				path, x.Name, current.LatestVersion, x.RotateEvery)
+			if err := r.client.RotateTransitKey(path, x.Name); err != nil {
+				return fmt.Errorf("failed to rotate the transit key: %s/keys/%s, error: %s", path, x.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeBackend unmounts a backend marked as absent if it's mounted
+func (r *syncCommand) removeBackend(backend *api.Backend) error {
+	path := backend.GetPath()
+	mounted, err := r.client.Mounts()
+	if err != nil {
+		return err
+	}
+	if _, found := mounted[path+"/"]; !found {
+		r.log.Infof("[backend: %s] state absent, the backend is not mounted", path)
+		return nil
+	}
+	r.log.Infof("[backend: %s] state absent, unmounting the backend", path)
+	if err := r.client.Client().Sys().Unmount(path); err != nil {
+		return fmt.Errorf("failed to unmount the backend: %s, error: %s", path, err)
+	}
+
+	return nil
+}
+
+// checkOneshotState refuses to synchronize when there is no state record and the existing backends
+// have oneshot settings, as they would all be executed again; the user must either seed the state
+// or explicitly rerun them
+func (r *syncCommand) checkOneshotState(backends []*api.Backend) error {
+	if r.state.Exists() || r.seedOneshots {
+		return nil
+	}
+	mounted, err := r.client.Mounts()
+	if err != nil {
+		return err
+	}
+
+	var list []string
+	for _, backend := range backends {
+		if backend.IsAbsent() {
+			continue
+		}
+		_, found := mounted[backend.GetPath()+"/"]
+		if !found && backend.MovedFrom != "" {
+			_, found = mounted[backend.GetMovedFrom()+"/"]
+		}
+		if !found {
+			continue
+		}
+		for _, c := range backend.Attrs {
+			uri := c.GetPath(backend.GetPath())
+			if c.IsOneshot() && !utils.ContainedIn(uri, r.rerunOneshots) {
+				list = append(list, uri)
+			}
+		}
+	}
+	if len(list) > 0 {
+		return fmt.Errorf("no vaultctl state record exists at: %s, the oneshot settings on the existing backends: %s "+
+			"would be executed again; use --seed-oneshot-state to record them as executed or --rerun-oneshot to execute them",
+			r.statePath, strings.Join(list, ","))
+	}
+
+	return nil
+}
+
+// applyOneshot executes a oneshot attribute if it has not already been recorded in the state, the
+// previous uri being the uri under the path the backend was moved from, if any
+func (r *syncCommand) applyOneshot(uri, previous string, attrs *api.Attributes, mounted bool) error {
+	hash := attrs.Hash(uri)
+	rerun := utils.ContainedIn(uri, r.rerunOneshots) || (previous != "" && utils.ContainedIn(previous, r.rerunOneshots))
+
+	switch {
+	case rerun:
+		r.log.Infof("[backend->config: %s] executing the oneshot setting again, as requested", uri)
+	case r.state.HasOneshot(hash):
+		r.log.Infof("[backend->config: %s] skipping the config, as the oneshot setting has been executed", uri)
+		return nil
+	case previous != "" && r.state.HasOneshot(attrs.Hash(previous)):
+		// step: the backend has been moved, so the oneshot was executed under the previous path
+		r.log.Infof("[backend->config: %s] skipping the config, as the oneshot setting was executed at: %s", uri, previous)
+		return r.state.RecordOneshot(hash, uri)
+	case mounted && r.seedOneshots:
+		// step: the backend was mounted prior to this sync, so we assume the oneshot has been executed
+		r.log.Infof("[backend->config: %s] seeding the state, recording the oneshot setting on the existing backend as executed", uri)
+		return r.state.RecordOneshot(hash, uri)
+	default:
+		r.log.Infof("[backend->config: %s] executing the oneshot setting", uri)
+	}
+
+	expanded, found := r.expandAttributes("backend->config", uri, attrs)
+	if !found {
+		return nil
+	}
+	if err := r.writeAttributes("backend->config", "PUT", uri, expanded); err != nil {
+		return err
+	}
+
+	return r.state.RecordOneshot(hash, uri)
+}
+
+// applyPKI bootstraps the pki certificate authorities, ordered so a signer is always bootstrapped
+// before the intermediates it signs, and exports the ca chains
+func (r *syncCommand) applyPKI(backends []*api.Backend) error {
+	declared := make(map[string]*api.Backend, 0)
+	for _, x := range backends {
+		if x.PKI != nil && !x.IsAbsent() {
+			declared[x.GetPath()] = x
+		}
+	}
+	if len(declared) <= 0 {
+		return nil
+	}
+
+	done := make(map[string]bool, 0)
+	for len(done) < len(declared) {
+		progressed := false
+		for _, x := range backends {
+			path := x.GetPath()
+			if declared[path] == nil || done[path] {
+				continue
+			}
+			// step: wait for the signer to be bootstrapped if it's part of the hierarchy
+			if signer := x.PKI.GetSigner(); declared[signer] != nil && !done[signer] {
+				continue
+			}
+			if err := r.applyCA(x); err != nil {
+				return err
+			}
+			done[path] = true
+			progressed = true
+		}
+		if !progressed {
+			return fmt.Errorf("the pki hierarchy has a cycle in the signed-by of the backends")
+		}
+	}
+
+	// step: export the ca chains
+	for _, x := range backends {
+		if declared[x.GetPath()] == nil {
+			continue
+		}
+		if err := r.exportCAChain(x, declared); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyCA bootstraps the certificate authority of a pki backend, if it does not already have one
+func (r *syncCommand) applyCA(backend *api.Backend) error {
+	path := backend.GetPath()
+	current, err := r.client.GetCA(path)
+	if err != nil {
+		return err
+	}
+	if current != "" {
+		r.log.Infof("[backend->pki: %s] certificate authority already exists, skipping", path)
+		return nil
+	}
+	ca := backend.PKI.GetCA()
+
+	if backend.PKI.Root != nil {
+		r.log.Infof("[backend->pki: %s] generating the root certificate authority, common name: %s", path, ca.CommonName)
+		return r.client.GenerateRoot(path, ca)
+	}
+
+	// step: ensure the signer has a certificate authority
+	signer := backend.PKI.GetSigner()
+	signerCA, err := r.client.GetCA(signer)
+	if err != nil {
+		return err
+	}
+	if signerCA == "" {
+		return fmt.Errorf("backend: %s, the signer: %s does not have a certificate authority", path, signer)
+	}
+
+	r.log.Infof("[backend->pki: %s] generating the intermediate certificate authority, common name: %s", path, ca.CommonName)
+	csr, err := r.client.GenerateIntermediate(path, ca)
+	if err != nil {
+		return err
+	}
+	r.log.Infof("[backend->pki: %s] signing the intermediate with: %s", path, signer)
+	certificate, err := r.client.SignIntermediate(signer, csr, ca)
+	if err != nil {
+		return err
+	}
+
+	return r.client.SetSigned(path, certificate)
+}
+
+// exportCAChain exports the ca chain of a pki backend to a file and or vault path
+func (r *syncCommand) exportCAChain(backend *api.Backend, declared map[string]*api.Backend) error {
+	if backend.PKI.ExportFile == "" && backend.PKI.ExportPath == "" {
+		return nil
+	}
+	path := backend.GetPath()
+
+	// step: walk up the hierarchy building the chain
+	var chain []string
+	for current := path; current != ""; {
+		ca, err := r.client.GetCA(current)
+		if err != nil {
+			return err
+		}
+		if ca == "" {
+			return fmt.Errorf("backend: %s, unable to export the ca chain, %s has no certificate authority", path, current)
+		}
+		chain = append(chain, ca)
+		next, found := declared[current]
+		if !found || next.PKI == nil {
+			break
+		}
+		current = next.PKI.GetSigner()
+	}
+	content := strings.Join(chain, "\n") + "\n"
+
+	if backend.PKI.ExportFile != "" {
+		existing, _ := ioutil.ReadFile(backend.PKI.ExportFile)
+		if string(existing) != content {
+			r.log.Infof("[backend->pki: %s] exporting the ca chain to file: %s", path, backend.PKI.ExportFile)
+			if err := ioutil.WriteFile(backend.PKI.ExportFile, []byte(content), 0644); err != nil {
+				return err
+			}
+		}
+	}
+	if backend.PKI.ExportPath != "" {
+		existing, err := r.client.GetSecret(backend.PKI.ExportPath)
+		if err != nil {
+			return err
+		}
+		if existing == nil || existing["ca_chain"] != content {
+			r.log.Infof("[backend->pki: %s] exporting the ca chain to: %s", path, backend.PKI.ExportPath)
+			if err := r.client.AddSecret(&api.Secret{
+				Path: backend.PKI.ExportPath,
+				Values: map[string]interface{}{
+					"certificate": chain[0],
+					"ca_chain":    content,
+				},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// pruneManaged removes any items in the managed collections of a backend which are no longer referenced
+func (r *syncCommand) pruneManaged(backend *api.Backend) error {
+	for _, x := range backend.Managed {
+		collection := fmt.Sprintf("%s/%s", backend.GetPath(), strings.Trim(x, "/"))
+		items, err := r.client.ListKeys(collection)
+		if err != nil {
+			return fmt.Errorf("failed to list the collection: %s, error: %s", collection, err)
+		}
+		declared := backend.GetManagedItems(x)
+		for _, name := range items {
+			if utils.ContainedIn(name, declared) {
+				continue
+			}
+			uri := fmt.Sprintf("%s/%s", collection, name)
+			r.log.Warnf("[backend: %s] %s no longer referenced, delete: %t", backend.GetPath(), uri, r.delete)
+			if !r.delete {
+				continue
+			}
+			if _, err := r.client.Client().Logical().Delete(uri); err != nil {
+				return fmt.Errorf("failed to delete: %s, error: %s", uri, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validationOptions returns the options used to validate the resources
+func (r *syncCommand) validationOptions() api.ValidationOptions {
+	return api.ValidationOptions{AllowUnknownTypes: r.allowUnknownTypes}
+}
+
+// checkServerSupports confirms the vault server supports the backend or auth type before mounting,
+// servers without a plugin catalog are left to accept or reject the type on mount
+func (r *syncCommand) checkServerSupports(kind, name string) error {
+	if !r.catalogLoaded {
+		catalog, err := r.client.Catalog()
+		if err != nil {
+			return err
+		}
+		if catalog == nil {
+			r.log.Debugf("the vault server does not expose a plugin catalog, unable to confirm the supported types")
+		}
+		r.catalog = catalog
+		r.catalogLoaded = true
+	}
+	if r.catalog == nil {
+		return nil
+	}
+
+	supported := r.catalog.HasSecret(name)
+	if kind == "auth" {
+		supported = r.catalog.HasAuth(name)
+	}
+	if !supported {
+		return fmt.Errorf("the %s type: %s is not supported by the vault server", kind, name)
+	}
+
+	return nil
+}
+
+// applyAttributes reads the current values of the attributes and only writes them if drifted,
+// oneshot attributes are always written as they are actions rather than configuration
+func (r *syncCommand) applyAttributes(kind, method, uri string, attrs *api.Attributes) error {
+	expanded, found := r.expandAttributes(kind, uri, attrs)
+	if !found {
+		return nil
+	}
+	// step: the outputs are only captured when the attributes are written
+	if !attrs.IsOneshot() {
+		drifted, err := r.client.HasAttributesDrifted(uri, expanded)
+		if err != nil {
+			return err
+		}
+		if !drifted {
+			r.log.Infof("[%s: %s] configuration unchanged, skipping", kind, uri)
+			return nil
+		}
+	}
+	r.log.Infof("[%s: %s] applying the configuration", kind, uri)
+
+	return r.writeAttributes(kind, method, uri, expanded)
+}
+
+// expandAttributes replaces the references to variables in the attributes, returning false if
+// a variable has not been set in this sync
+func (r *syncCommand) expandAttributes(kind, uri string, attrs *api.Attributes) (*api.Attributes, bool) {
+	expanded, missing := attrs.Expand(r.variables)
+	if len(missing) > 0 {
+		r.log.Warnf("[%s: %s] skipping the configuration, the variables: %s have not been set in this sync",
+			kind, uri, strings.Join(missing, ","))
+		return nil, false
+	}
+
+	return expanded, true
+}
+
+// writeAttributes writes the attributes to vault and processes any outputs from the response
+func (r *syncCommand) writeAttributes(kind, method, uri string, attrs *api.Attributes) error {
+	resp, err := r.client.WriteAttributes(method, uri, attrs)
+	if err != nil {
+		return err
+	}
+	outputs, err := attrs.GetOutputs()
+	if err != nil {
+		return err
+	}
+	if len(outputs) > 0 && resp == nil {
+		return fmt.Errorf("%s: %s, no response was returned to extract the outputs from", kind, uri)
+	}
+
+	for _, x := range outputs {
+		field, err := utils.JSONPath(resp, x.Path)
+		if err != nil {
+			return fmt.Errorf("%s: %s, unable to extract the output, error: %s", kind, uri, err)
+		}
+		value, ok := field.(string)
+		if !ok {
+			encoded, err := json.Marshal(field)
+			if err != nil {
+				return err
+			}
+			value = string(encoded)
+		}
+		if x.Variable != "" {
+			r.log.Infof("[%s: %s] setting the variable: %s from the output: %s", kind, uri, x.Variable, x.Path)
+			r.variables[x.Variable] = value
+		}
+		if x.File != "" {
+			r.log.Infof("[%s: %s] writing the output: %s to file: %s", kind, uri, x.Path, x.File)
+			if err := ioutil.WriteFile(x.File, []byte(value), x.GetMode()); err != nil {
+				return err
+			}
+			if err := os.Chmod(x.File, x.GetMode()); err != nil {
+				return err
+			}
+		}
+		if x.VaultPath != "" {
+			r.log.Infof("[%s: %s] writing the output: %s to: %s", kind, uri, x.Path, x.VaultPath)
+			values, err := r.client.GetSecret(x.VaultPath)
+			if err != nil {
+				return err
+			}
+			if values == nil {
+				values = make(map[string]interface{}, 0)
+			}
+			values[x.GetKey()] = value
+			if err := r.client.AddSecret(&api.Secret{Path: x.VaultPath, Values: values}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// tuneBackend checks the mount configuration of an existing backend and tunes it if drifted
+func (r *syncCommand) tuneBackend(backend *api.Backend, mount *v.MountOutput) error {
+	path := backend.GetPath()
+	// step: the description cannot be tuned, so the drift is only reported
+	if mount.Description != backend.Description {
+		r.log.Warnf("[backend: %s] description has drifted, vault: '%s', config: '%s'", path, mount.Description, backend.Description)
+	}
+
+	config, err := r.client.Client().Sys().MountConfig(path)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the mount config for backend: %s, error: %s", path, err)
+	}
+
+	drifted := false
+	if hasTTLDrifted(backend.DefaultLeaseTTL, config.DefaultLeaseTTL) {
+		r.log.Infof("[backend: %s] default lease ttl has drifted, vault: %ds, config: %s", path, config.DefaultLeaseTTL, backend.GetDefaultTTL())
+		drifted = true
+	}
+	if hasTTLDrifted(backend.MaxLeaseTTL, config.MaxLeaseTTL) {
+		r.log.Infof("[backend: %s] max lease ttl has drifted, vault: %ds, config: %s", path, config.MaxLeaseTTL, backend.GetMaxTTL())
+		drifted = true
+	}
+	if !drifted {
+		return nil
+	}
+
+	r.log.Infof("[backend: %s] tuning the mount, default ttl: %s, max ttl: %s", path, backend.GetDefaultTTL(), backend.GetMaxTTL())
+	if err := r.client.Client().Sys().TuneMount(path, v.MountConfigInput{
+		DefaultLeaseTTL: backend.GetDefaultTTL(),
+		MaxLeaseTTL:     backend.GetMaxTTL(),
+	}); err != nil {
+		return fmt.Errorf("failed to tune the backend: %s, error: %s", path, err)
+	}
+
+	return nil
+}
+
+// hasTTLDrifted checks if the declared ttl differs from the seconds in the mount config, a ttl of
+// zero in the config means the system default, which we leave alone
+func hasTTLDrifted(declared time.Duration, current int) bool {
+	return declared > 0 && int(declared/time.Second) != current
+}
+
 // applySecrets synchronizes the secrets in vault
 func (r *syncCommand) applySecrets(secrets []*api.Secret) error {
-	log.Infof("%s", color.GreenString("-> synchronizing the secrets with vault, secrets: %d", len(secrets)))
+	r.log.Infof("%s", color.GreenString("-> synchronizing the secrets with vault, secrets: %d", len(secrets)))
 
 	for _, s := range secrets {
 		// step: validate the secret
@@ -361,7 +1247,65 @@ func (r *syncCommand) applySecrets(secrets []*api.Secret) error {
 			return err
 		}
 
-		log.Infof("[secret: %s] adding the secret", s.Path)
+		// step: are we removing the secret?
+		if s.IsAbsent() {
+			current, err := r.client.GetSecret(s.Path)
+			if err != nil {
+				return err
+			}
+			if current == nil {
+				r.log.Infof("[secret: %s] state absent, the secret does not exist", s.Path)
+				continue
+			}
+			r.log.Infof("[secret: %s] state absent, deleting the secret", s.Path)
+			if err := r.client.DeleteSecret(s.Path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// step: read in the values, including any files
+		values, err := s.GetValues()
+		if err != nil {
+			return err
+		}
+		// step: read the current secret
+		current, err := r.client.GetSecret(s.Path)
+		if err != nil {
+			return err
+		}
+
+		// step: generate any values which are missing or being rotated, otherwise keep the current
+		for k, v := range values {
+			generator, found, err := api.GetGenerator(v)
+			if err != nil {
+				return err
+			}
+			if !found {
+				continue
+			}
+			if existing, found := current[k]; found && !s.IsRotated(k, r.rotateSecrets) {
+				values[k] = existing
+				continue
+			}
+			r.log.Infof("[secret: %s] generating the value for the key: %s", s.Path, k)
+			if values[k], err = generator.Generate(); err != nil {
+				return err
+			}
+		}
+		s = &api.Secret{Path: s.Path, Values: s.Reconcile(values, current)}
+
+		// step: compare against the current secret
+		diff := s.Diff(current)
+		if !diff.HasChanged() {
+			r.log.Infof("[secret: %s] secret unchanged, skipping", s.Path)
+			continue
+		}
+		if current == nil {
+			r.log.Infof("[secret: %s] adding the secret, %s", s.Path, r.describeSecretDiff(s, current, diff))
+		} else {
+			r.log.Infof("[secret: %s] updating the secret, %s", s.Path, r.describeSecretDiff(s, current, diff))
+		}
 
 		// step: apply the secret
 		if err := r.client.AddSecret(s); err != nil {
@@ -372,10 +1316,32 @@ func (r *syncCommand) applySecrets(secrets []*api.Secret) error {
 	return nil
 }
 
+// describeSecretDiff describes the changes to a secret, with the values masked or shown as hashes
+func (r *syncCommand) describeSecretDiff(secret *api.Secret, current map[string]interface{}, diff *api.SecretDiff) string {
+	var added, changed []string
+	for _, k := range diff.Added {
+		if r.showHashes {
+			k = fmt.Sprintf("%s(%s)", k, api.HashValue(secret.Values[k]))
+		}
+		added = append(added, k)
+	}
+	for _, k := range diff.Changed {
+		if r.showHashes {
+			k = fmt.Sprintf("%s(%s -> %s)", k, api.HashValue(current[k]), api.HashValue(secret.Values[k]))
+		}
+		changed = append(changed, k)
+	}
+
+	return fmt.Sprintf("added: [%s], changed: [%s], removed: [%s]",
+		strings.Join(added, ","), strings.Join(changed, ","), strings.Join(diff.Removed, ","))
+}
 
 // validateAction validates the inputs from the command line
 func (r *syncCommand) validateAction(cx *cli.Context) error {
 	r.configFiles = cx.StringSlice("config")
+	r.targets = cx.StringSlice("target")
+	r.rerunOneshots = cx.StringSlice("rerun-oneshot")
+	r.rotateSecrets = cx.StringSlice("rotate")
 
 	// step: check the skips
 	if r.skipBackends && r.skipPolicies && r.skipUsers {
@@ -424,6 +1390,11 @@ func (r *syncCommand) getCommand() cli.Command {
 				Usage:       "a full sync will also check the resources are still reference and attempt to delete",
 				Destination: &r.fullsync,
 			},
+			cli.BoolFlag{
+				Name:        "prune-users",
+				Usage:       "a full sync will also check the users in the auth backends are still referenced",
+				Destination: &r.pruneUsers,
+			},
 			cli.BoolFlag{
 				Name:        "delete",
 				Usage:       "wheather to delete resources which are no longer referenced",
@@ -455,6 +1426,44 @@ func (r *syncCommand) getCommand() cli.Command {
 				Value:       "*.yaml",
 				Destination: &r.configExtension,
 			},
+			cli.StringSliceFlag{
+				Name:  "t, target",
+				Usage: "the name of a target defined in the configuration to synchronize, defaults to all",
+			},
+			cli.BoolFlag{
+				Name:        "allow-unknown-types",
+				Usage:       "permit backend and auth types unknown to vaultctl, relying on vault to accept them",
+				Destination: &r.allowUnknownTypes,
+			},
+			cli.StringFlag{
+				Name:        "state-path",
+				Usage:       "the vault path used to record the state of vaultctl, i.e. the executed oneshot settings",
+				Value:       "secret/vaultctl/state",
+				Destination: &r.statePath,
+			},
+			cli.StringSliceFlag{
+				Name:  "rerun-oneshot",
+				Usage: "the full uri of a oneshot setting to execute again, i.e. platform/pki/root/generate/internal",
+			},
+			cli.BoolFlag{
+				Name:        "seed-oneshot-state",
+				Usage:       "record the unrecorded oneshot settings on existing backends as executed rather than running them",
+				Destination: &r.seedOneshots,
+			},
+			cli.StringSliceFlag{
+				Name:  "rotate",
+				Usage: "regenerate the generated values of a secret path or path:key, i.e. platform/secrets/db:password",
+			},
+			cli.BoolFlag{
+				Name:        "show-secret-hashes",
+				Usage:       "show the hashes of the changed secret values, rather than masking them",
+				Destination: &r.showHashes,
+			},
+			cli.BoolFlag{
+				Name:        "parallel",
+				Usage:       "whether to synchronize the targets in parallel",
+				Destination: &r.parallel,
+			},
 		},
 	}
 }
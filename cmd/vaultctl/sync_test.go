@@ -0,0 +1,173 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/api"
+	"github.com/UKHomeOffice/vaultctl/pkg/vault"
+
+	log "github.com/Sirupsen/logrus"
+	v "github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasTTLDrifted(t *testing.T) {
+	tests := []struct {
+		Declared time.Duration
+		Current  int
+		Drifted  bool
+	}{
+		{Declared: 0, Current: 2764800},
+		{Declared: time.Hour, Current: 3600},
+		{Declared: 90 * time.Second, Current: 90},
+		{Declared: 720 * time.Hour, Current: 2592000},
+		{Declared: time.Hour, Current: 7200, Drifted: true},
+		{Declared: time.Hour, Current: 0, Drifted: true},
+		{Declared: 30 * time.Minute, Current: 3600, Drifted: true},
+	}
+	for i, c := range tests {
+		assert.Equal(t, c.Drifted, hasTTLDrifted(c.Declared, c.Current), "case %d not as expected", i)
+	}
+}
+
+func TestTuneBackend(t *testing.T) {
+	tests := []struct {
+		Backend *api.Backend
+		Tuned   map[string]interface{}
+	}{
+		{
+			Backend: &api.Backend{Path: "pki", DefaultLeaseTTL: time.Hour, MaxLeaseTTL: 24 * time.Hour},
+		},
+		{
+			Backend: &api.Backend{Path: "pki"},
+		},
+		{
+			Backend: &api.Backend{Path: "pki", Description: "changed", MaxLeaseTTL: 24 * time.Hour},
+		},
+		{
+			Backend: &api.Backend{Path: "pki", DefaultLeaseTTL: 2 * time.Hour, MaxLeaseTTL: 24 * time.Hour},
+			Tuned:   map[string]interface{}{"default_lease_ttl": "2h0m0s", "max_lease_ttl": "24h0m0s"},
+		},
+		{
+			Backend: &api.Backend{Path: "pki", MaxLeaseTTL: 48 * time.Hour},
+			Tuned:   map[string]interface{}{"default_lease_ttl": "system", "max_lease_ttl": "48h0m0s"},
+		},
+	}
+	for i, c := range tests {
+		var tuned map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != "/v1/sys/mounts/pki/tune" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if req.Method == "POST" {
+				json.NewDecoder(req.Body).Decode(&tuned)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"default_lease_ttl": 3600, "max_lease_ttl": 86400})
+		}))
+		client, err := vault.New(server.URL, "", "", "", "token")
+		if !assert.NoError(t, err) {
+			server.Close()
+			continue
+		}
+		cmd := &syncCommand{client: client, log: log.WithField("target", "test")}
+		err = cmd.tuneBackend(c.Backend, &v.MountOutput{Type: "pki", Description: "test"})
+		server.Close()
+		if !assert.NoError(t, err, "case %d should have not errored", i) {
+			continue
+		}
+		assert.Equal(t, c.Tuned, tuned, "case %d tuning not as expected", i)
+	}
+}
+
+func TestApplyOneshotMovedBackend(t *testing.T) {
+	attrs := &api.Attributes{"uri": "root/generate/internal", "common_name": "example.com", "oneshot": true}
+	cmd, fake, closer := newTestSecretCommand(t, map[string]map[string]interface{}{
+		"secret/vaultctl/state": {"oneshot-" + attrs.Hash("pki/root/generate/internal"): "executed"},
+	})
+	defer closer()
+
+	sync := &syncCommand{client: cmd.source, log: log.WithField("target", "test")}
+	state, err := sync.client.LoadState("secret/vaultctl/state")
+	if !assert.NoError(t, err) {
+		return
+	}
+	sync.state = state
+
+	err = sync.applyOneshot("platform/pki/root/generate/internal", "pki/root/generate/internal", attrs, true)
+	assert.NoError(t, err)
+	_, executed := fake.secrets["platform/pki/root/generate/internal"]
+	assert.False(t, executed, "the oneshot should not have been executed again")
+	assert.Contains(t, fake.secrets["secret/vaultctl/state"], "oneshot-"+attrs.Hash("platform/pki/root/generate/internal"))
+}
+
+func TestCheckLocalFiles(t *testing.T) {
+	output := func(o map[string]interface{}) []*api.Attributes {
+		return []*api.Attributes{{"uri": "issue/web", "outputs": []interface{}{o}}}
+	}
+	tests := []struct {
+		Resources *resources
+		Ok        bool
+	}{
+		{Resources: &resources{}, Ok: true},
+		{
+			Resources: &resources{users: []*api.User{{UserPass: &api.UserPass{Username: "test", PasswordFile: "test.yml"}}}},
+		},
+		{
+			Resources: &resources{users: []*api.User{{UserPass: &api.UserPass{Username: "test", PasswordPath: "secret/test"}}}},
+			Ok:        true,
+		},
+		{
+			Resources: &resources{backends: []*api.Backend{{Path: "pki", Attrs: output(map[string]interface{}{"path": "data.certificate", "file": "web.pem"})}}},
+		},
+		{
+			Resources: &resources{auths: []*api.Auth{{Path: "cert", Attrs: output(map[string]interface{}{"path": "data.certificate", "file": "web.pem"})}}},
+		},
+		{
+			Resources: &resources{backends: []*api.Backend{{Path: "pki", Attrs: output(map[string]interface{}{"path": "data.certificate", "vault-path": "secret/web"})}}},
+			Ok:        true,
+		},
+		{
+			Resources: &resources{backends: []*api.Backend{{Path: "pki", PKI: &api.PKI{ExportFile: "ca.pem"}}}},
+		},
+		{
+			Resources: &resources{backends: []*api.Backend{{Path: "pki", PKI: &api.PKI{ExportPath: "secret/ca"}}}},
+			Ok:        true,
+		},
+		{
+			Resources: &resources{backends: []*api.Backend{{Path: "pki", State: api.AbsentState,
+				Attrs: output(map[string]interface{}{"path": "data.certificate", "file": "web.pem"})}}},
+			Ok: true,
+		},
+	}
+	for i, c := range tests {
+		cmd := &syncCommand{resources: c.Resources}
+		err := cmd.checkLocalFiles()
+		if !c.Ok {
+			assert.Error(t, err, "case %d should have errored", i)
+		} else {
+			assert.NoError(t, err, "case %d should have not errored", i)
+		}
+	}
+}
@@ -17,6 +17,7 @@ package main
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/UKHomeOffice/vaultctl/pkg/api"
 	"github.com/UKHomeOffice/vaultctl/pkg/utils"
@@ -88,27 +89,116 @@ func parseConfigFiles(files []string) (*resources, error) {
 
 		// step: appends the elements
 		r.users = append(r.users, cfg.Users...)
+		// step: resolve the certificates relative to the config file
+		for _, x := range cfg.Users {
+			if x.Cert != nil && x.Cert.Certificate != "" && !filepath.IsAbs(x.Cert.Certificate) {
+				x.Cert.Certificate = filepath.Join(filepath.Dir(c), x.Cert.Certificate)
+			}
+		}
 		r.backends = append(r.backends, cfg.Backends...)
 		r.secrets = append(r.secrets, cfg.Secrets...)
+		// step: resolve the secret files relative to the config file
+		for _, x := range cfg.Secrets {
+			for _, f := range x.Files {
+				if f != nil && f.Path != "" && !filepath.IsAbs(f.Path) {
+					f.Path = filepath.Join(filepath.Dir(c), f.Path)
+				}
+			}
+		}
 		r.auths = append(r.auths, cfg.Auths...)
 		r.policies = append(r.policies, cfg.Policies...)
+		r.targets = append(r.targets, cfg.Targets...)
+		if cfg.PasswordPolicy != nil {
+			if r.passwordPolicy != nil {
+				return nil, fmt.Errorf("the password policy has been defined more than once, file: %s", c)
+			}
+			r.passwordPolicy = cfg.PasswordPolicy
+		}
 	}
 
 	return r, nil
 }
 
+// validateResources validates the resources offline, i.e. without access to vault
+func validateResources(r *resources, options api.ValidationOptions) error {
+	for _, x := range r.auths {
+		if err := x.IsValidWith(options); err != nil {
+			return fmt.Errorf("auth: %s invalid, error: %s", x.Path, err)
+		}
+	}
+	for _, x := range r.policies {
+		if err := x.IsValid(); err != nil {
+			return err
+		}
+	}
+	for _, x := range r.backends {
+		if err := x.IsValidWith(options); err != nil {
+			return err
+		}
+	}
+	for _, x := range r.secrets {
+		if err := x.IsValid(); err != nil {
+			return fmt.Errorf("secret: %s invalid, error: %s", x.Path, err)
+		}
+	}
+	for _, x := range r.targets {
+		if err := x.IsValid(); err != nil {
+			return err
+		}
+	}
+	if r.passwordPolicy != nil {
+		if err := r.passwordPolicy.IsValid(); err != nil {
+			return err
+		}
+	}
+	for _, x := range r.users {
+		if err := x.IsValid(); err != nil {
+			return fmt.Errorf("user: %s invalid, error: %s", x.Username(), err)
+		}
+		if x.UserPass == nil || r.passwordPolicy == nil || x.IsAbsent() {
+			continue
+		}
+		if x.UserPass.PasswordExemption != "" {
+			log.Warnf("[user: %s] password is exempt from the password policy, reason: %s", x.Username(), x.UserPass.PasswordExemption)
+		}
+		if err := r.passwordPolicy.CheckPassword(x.UserPass); err != nil {
+			return fmt.Errorf("password policy violation, %s", err)
+		}
+	}
+
+	return nil
+}
+
 // getVaultClient retrieves a vault client for use
 func getVaultClient(cx *cli.Context) (*vault.Client, error) {
-	host := cx.GlobalString("vault-addr")
-	username := cx.GlobalString("vault-username")
-	password := cx.GlobalString("vault-password")
-	token := cx.GlobalString("vault-token")
-	creds := cx.GlobalString("credentials")
+	return newVaultClient(
+		cx.GlobalString("vault-addr"),
+		cx.GlobalString("vault-username"),
+		cx.GlobalString("vault-password"),
+		cx.GlobalString("vault-token"),
+		cx.GlobalString("credentials"))
+}
+
+// getTargetClient retrieves a vault client for a target, falling back to the global
+// authentication options when the target does not specify any
+func getTargetClient(cx *cli.Context, target *api.Target) (*vault.Client, error) {
+	if target.Token == "" && target.Username == "" && target.Credentials == "" {
+		return newVaultClient(target.Address,
+			cx.GlobalString("vault-username"),
+			cx.GlobalString("vault-password"),
+			cx.GlobalString("vault-token"),
+			cx.GlobalString("credentials"))
+	}
+
+	return newVaultClient(target.Address, target.Username, target.Password, target.Token, target.Credentials)
+}
 
+// newVaultClient validates the authentication options and creates a vault client
+func newVaultClient(host, username, password, token, creds string) (*vault.Client, error) {
 	// step: validate we have the requirements
 	if creds != "" {
 		if !utils.IsFile(creds) {
-			printUsage("the vault credentials file does not exist")
+			return nil, fmt.Errorf("the vault credentials file: %s does not exist", creds)
 		}
 	} else if token == "" {
 		if username == "" || password == "" {
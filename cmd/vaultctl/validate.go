@@ -0,0 +1,97 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/api"
+	"github.com/UKHomeOffice/vaultctl/pkg/utils"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+)
+
+type validateCommand struct {
+	// the config extension
+	configExtension string
+	// whether to permit backend and auth types unknown to vaultctl
+	allowUnknownTypes bool
+}
+
+// newValidateCommand creates a new validate command
+func newValidateCommand() cli.Command {
+	return new(validateCommand).getCommand()
+}
+
+// action validates the configuration files without connecting to vault
+func (r *validateCommand) action(cx *cli.Context) error {
+	files := cx.StringSlice("config")
+	// step: get the files from any config directories
+	list, err := utils.FindFilesInDirectory(cx.StringSlice("config-dir"), r.configExtension)
+	if err != nil {
+		return err
+	}
+	files = append(files, list...)
+	if len(files) <= 0 {
+		return fmt.Errorf("you have not specified any configuration files")
+	}
+
+	resources, err := parseConfigFiles(files)
+	if err != nil {
+		return err
+	}
+	if err := validateResources(resources, api.ValidationOptions{AllowUnknownTypes: r.allowUnknownTypes}); err != nil {
+		return err
+	}
+
+	log.Infof("validated the configuration, files: %d, users: %d, policies: %d, backends: %d, secrets: %d",
+		len(files), len(resources.users), len(resources.policies), len(resources.backends), len(resources.secrets))
+
+	return nil
+}
+
+// getCommand returns the command set
+func (r *validateCommand) getCommand() cli.Command {
+	return cli.Command{
+		Name:  "validate",
+		Usage: "validates the configuration files offline, without connecting to vault",
+		Flags: []cli.Flag{
+			cli.StringSliceFlag{
+				Name:  "c, config",
+				Usage: "the path to a configuration file containing users, backends and or secrets",
+			},
+			cli.StringSliceFlag{
+				Name:  "C, config-dir",
+				Usage: "the path to a directory containing one of more config files",
+			},
+			cli.StringFlag{
+				Name:        "config-extension",
+				Usage:       "when using a config-dir, the file extension to glob",
+				Value:       "*.yaml",
+				Destination: &r.configExtension,
+			},
+			cli.BoolFlag{
+				Name:        "allow-unknown-types",
+				Usage:       "permit backend and auth types unknown to vaultctl",
+				Destination: &r.allowUnknownTypes,
+			},
+		},
+		Action: func(cx *cli.Context) {
+			executeCommand(cx, r.action)
+		},
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/utils"
+	"github.com/pborman/uuid"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// RandomGenerator generates a random string from a charset
+	RandomGenerator = "random"
+	// HexGenerator generates a random hex string
+	HexGenerator = "hex"
+	// UUIDGenerator generates a random uuid
+	UUIDGenerator = "uuid"
+	// defaultGeneratedLength is the length of a generated value when not specified
+	defaultGeneratedLength = 32
+	// maxGeneratedLength is the maximum length of a generated value
+	maxGeneratedLength = 4096
+)
+
+// generatorCharsets are the named charsets of a random generator
+var generatorCharsets = map[string]string{
+	"alnum":    utils.AlphaNumeric,
+	"alpha":    "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"numeric":  "0123456789",
+	"password": utils.PasswordCharset,
+}
+
+// GetGenerator returns the generator of a secret value, if the value is a generator spec, i.e.
+// {generate: uuid} or {generate: {length: 32, charset: alnum}}
+func GetGenerator(value interface{}) (*Generator, bool, error) {
+	var spec interface{}
+	switch x := value.(type) {
+	case map[string]interface{}:
+		if len(x) != 1 || x["generate"] == nil {
+			return nil, false, nil
+		}
+		spec = x["generate"]
+	case map[interface{}]interface{}:
+		if len(x) != 1 || x["generate"] == nil {
+			return nil, false, nil
+		}
+		spec = x["generate"]
+	default:
+		return nil, false, nil
+	}
+
+	// step: the short form is the generator type
+	if name, ok := spec.(string); ok {
+		return &Generator{Type: name}, true, nil
+	}
+	// step: the spec is decoded as a generic map, so we round trip it into the type
+	content, err := yaml.Marshal(spec)
+	if err != nil {
+		return nil, true, err
+	}
+	generator := &Generator{}
+	if err := yaml.Unmarshal(content, generator); err != nil {
+		return nil, true, fmt.Errorf("invalid generate spec, error: %s", err)
+	}
+
+	return generator, true, nil
+}
+
+// GetType returns the type of generator
+func (r Generator) GetType() string {
+	if r.Type == "" {
+		return RandomGenerator
+	}
+
+	return r.Type
+}
+
+// GetLength returns the length of the generated value
+func (r Generator) GetLength() int {
+	if r.Length == 0 {
+		return defaultGeneratedLength
+	}
+
+	return r.Length
+}
+
+// GetCharset returns the charset of a random generator
+func (r Generator) GetCharset() string {
+	if r.Charset == "" {
+		return utils.AlphaNumeric
+	}
+
+	return generatorCharsets[r.Charset]
+}
+
+// IsValid validates the generator is ok
+func (r Generator) IsValid() error {
+	switch r.GetType() {
+	case RandomGenerator:
+		if r.Charset != "" && generatorCharsets[r.Charset] == "" {
+			return fmt.Errorf("unknown charset: %s, must be one of alnum, alpha, numeric or password", r.Charset)
+		}
+	case HexGenerator:
+		if r.Charset != "" {
+			return fmt.Errorf("a charset cannot be used with a hex generator")
+		}
+	case UUIDGenerator:
+		if r.Length != 0 || r.Charset != "" {
+			return fmt.Errorf("a length or charset cannot be used with a uuid generator")
+		}
+	default:
+		return fmt.Errorf("unknown generator: %s, must be random, hex or uuid", r.Type)
+	}
+	if r.Length < 0 || r.Length > maxGeneratedLength {
+		return fmt.Errorf("the generated length must be between 1 and %d", maxGeneratedLength)
+	}
+
+	return nil
+}
+
+// Generate generates a new value
+func (r Generator) Generate() (string, error) {
+	switch r.GetType() {
+	case UUIDGenerator:
+		return uuid.NewRandom().String(), nil
+	case HexGenerator:
+		return utils.RandomString(r.GetLength(), "0123456789abcdef")
+	default:
+		return utils.RandomString(r.GetLength(), r.GetCharset())
+	}
+}
+
+// IsRotated checks if the key of the secret is selected by a list of rotate selectors, where
+// a selector is the secret path or path:key
+func (r *Secret) IsRotated(key string, selectors []string) bool {
+	path := strings.Trim(r.Path, "/")
+	for _, x := range selectors {
+		x = strings.Trim(x, "/")
+		if x == path || x == path+":"+key {
+			return true
+		}
+	}
+
+	return false
+}
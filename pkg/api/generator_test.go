@@ -0,0 +1,112 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGenerator(t *testing.T) {
+	tests := []struct {
+		Value     interface{}
+		Generator *Generator
+		Found     bool
+		Ok        bool
+	}{
+		{Value: "plain", Ok: true},
+		{Value: map[string]interface{}{"other": "uuid"}, Ok: true},
+		{Value: map[string]interface{}{"generate": "uuid", "other": 1}, Ok: true},
+		{Value: map[string]interface{}{"generate": "uuid"}, Generator: &Generator{Type: "uuid"}, Found: true, Ok: true},
+		{
+			Value:     map[interface{}]interface{}{"generate": map[interface{}]interface{}{"length": 16, "charset": "alpha"}},
+			Generator: &Generator{Length: 16, Charset: "alpha"},
+			Found:     true,
+			Ok:        true,
+		},
+		{
+			Value:     map[string]interface{}{"generate": map[string]interface{}{"type": "hex", "length": 64}},
+			Generator: &Generator{Type: "hex", Length: 64},
+			Found:     true,
+			Ok:        true,
+		},
+		{Value: map[string]interface{}{"generate": []interface{}{"uuid"}}, Found: true},
+	}
+	for i, c := range tests {
+		generator, found, err := GetGenerator(c.Value)
+		if !c.Ok {
+			assert.Error(t, err, "case %d should have errored", i)
+			continue
+		}
+		assert.NoError(t, err, "case %d should have not errored", i)
+		assert.Equal(t, c.Found, found, "case %d found not as expected", i)
+		assert.Equal(t, c.Generator, generator, "case %d generator not as expected", i)
+	}
+}
+
+func TestGeneratorIsValid(t *testing.T) {
+	tests := []struct {
+		Generator Generator
+		Ok        bool
+	}{
+		{Generator: Generator{}, Ok: true},
+		{Generator: Generator{Length: 16, Charset: "numeric"}, Ok: true},
+		{Generator: Generator{Type: "hex", Length: 64}, Ok: true},
+		{Generator: Generator{Type: "uuid"}, Ok: true},
+		{Generator: Generator{Type: "base32"}},
+		{Generator: Generator{Charset: "greek"}},
+		{Generator: Generator{Type: "hex", Charset: "alnum"}},
+		{Generator: Generator{Type: "uuid", Length: 10}},
+		{Generator: Generator{Length: -1}},
+		{Generator: Generator{Length: maxGeneratedLength + 1}},
+	}
+	for i, c := range tests {
+		err := c.Generator.IsValid()
+		if !c.Ok {
+			assert.Error(t, err, "case %d should have errored", i)
+		} else {
+			assert.NoError(t, err, "case %d should have not errored", i)
+		}
+	}
+}
+
+func TestGeneratorGenerate(t *testing.T) {
+	tests := []struct {
+		Generator Generator
+		Regex     string
+	}{
+		{Generator: Generator{}, Regex: "^[a-zA-Z0-9]{32}$"},
+		{Generator: Generator{Length: 10, Charset: "numeric"}, Regex: "^[0-9]{10}$"},
+		{Generator: Generator{Type: "hex", Length: 64}, Regex: "^[0-9a-f]{64}$"},
+		{Generator: Generator{Type: "uuid"}, Regex: "^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[0-9a-f]{4}-[0-9a-f]{12}$"},
+	}
+	for i, c := range tests {
+		value, err := c.Generator.Generate()
+		assert.NoError(t, err, "case %d should have not errored", i)
+		assert.Regexp(t, regexp.MustCompile(c.Regex), value, "case %d value not as expected", i)
+	}
+}
+
+func TestSecretIsRotated(t *testing.T) {
+	secret := &Secret{Path: "platform/secrets/db"}
+	assert.True(t, secret.IsRotated("password", []string{"platform/secrets/db"}))
+	assert.True(t, secret.IsRotated("password", []string{"/platform/secrets/db:password"}))
+	assert.False(t, secret.IsRotated("hmac", []string{"platform/secrets/db:password"}))
+	assert.False(t, secret.IsRotated("password", []string{"platform/secrets"}))
+	assert.False(t, secret.IsRotated("password", nil))
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// commonPasswords is a list of commonly used passwords which are never permitted
+var commonPasswords = []string{
+	"123456", "1234567", "12345678", "123456789", "1234567890", "111111", "000000",
+	"password", "password1", "password12", "password123", "passw0rd", "p@ssw0rd", "p@ssword",
+	"qwerty", "qwerty123", "qwertyuiop", "abc123", "letmein", "welcome", "welcome1",
+	"monkey", "dragon", "master", "login", "admin", "admin123", "administrator",
+	"changeme", "secret", "trustno1", "iloveyou", "sunshine", "princess", "football",
+	"baseball", "superman", "starwars", "whatever", "shadow", "default", "root", "toor",
+	"vault", "test", "test123", "guest",
+}
+
+// IsValid validates the password policy is ok
+func (r PasswordPolicy) IsValid() error {
+	if r.MinLength < 0 {
+		return fmt.Errorf("the password policy min-length must be positive")
+	}
+
+	return nil
+}
+
+// Check validates the password for a user against the policy
+func (r PasswordPolicy) Check(username, password string) error {
+	if len(password) < r.MinLength {
+		return fmt.Errorf("password must be at least %d characters", r.MinLength)
+	}
+	if username != "" && strings.EqualFold(password, username) {
+		return fmt.Errorf("password cannot be the same as the username")
+	}
+	lowered := strings.ToLower(password)
+	for _, list := range [][]string{commonPasswords, r.DenyList} {
+		for _, x := range list {
+			if lowered == strings.ToLower(x) {
+				return fmt.Errorf("password is a commonly used or denied password")
+			}
+		}
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, x := range password {
+		switch {
+		case unicode.IsUpper(x):
+			upper = true
+		case unicode.IsLower(x):
+			lower = true
+		case unicode.IsDigit(x):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	if r.RequireUpper && !upper {
+		return fmt.Errorf("password must contain an uppercase letter")
+	}
+	if r.RequireLower && !lower {
+		return fmt.Errorf("password must contain a lowercase letter")
+	}
+	if r.RequireDigit && !digit {
+		return fmt.Errorf("password must contain a digit")
+	}
+	if r.RequireSymbol && !symbol {
+		return fmt.Errorf("password must contain a symbol")
+	}
+
+	return nil
+}
+
+// CheckPassword validates the password of a userpass user against the policy, generated passwords
+// and users with a recorded exemption are skipped
+func (r PasswordPolicy) CheckPassword(user *UserPass) error {
+	if user.IsGenerated() || user.PasswordExemption != "" {
+		return nil
+	}
+	if err := r.Check(user.Username, user.Password); err != nil {
+		return fmt.Errorf("user: %s, %s", user.Username, err)
+	}
+
+	return nil
+}
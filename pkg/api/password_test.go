@@ -0,0 +1,70 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := &PasswordPolicy{
+		MinLength:     10,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		DenyList:      []string{"Company2016!!"},
+	}
+	tests := []struct {
+		Username string
+		Password string
+		Ok       bool
+	}{
+		{Username: "test", Password: "short"},
+		{Username: "test", Password: "nouppercase1!"},
+		{Username: "test", Password: "NOLOWERCASE1!"},
+		{Username: "test", Password: "NoDigitsHere!"},
+		{Username: "test", Password: "NoSymbols123"},
+		{Username: "test", Password: "company2016!!"},
+		{Username: "Username1!x", Password: "username1!X"},
+		{Username: "test", Password: "Sup3r-Secure!", Ok: true},
+	}
+
+	for i, c := range tests {
+		err := policy.Check(c.Username, c.Password)
+		if !c.Ok {
+			assert.Error(t, err, "case %d should have errored", i)
+		} else {
+			assert.NoError(t, err, "case %d should have not errored", i)
+		}
+	}
+}
+
+func TestPasswordPolicyCommonPasswords(t *testing.T) {
+	policy := &PasswordPolicy{}
+	assert.Error(t, policy.Check("test", "password1"))
+	assert.Error(t, policy.Check("test", "PASSWORD"))
+	assert.NoError(t, policy.Check("test", "not-a-common-one"))
+}
+
+func TestPasswordPolicyCheckPassword(t *testing.T) {
+	policy := &PasswordPolicy{MinLength: 10}
+	assert.Error(t, policy.CheckPassword(&UserPass{Username: "test", Password: "password1"}))
+	assert.NoError(t, policy.CheckPassword(&UserPass{Username: "test", PasswordPath: "secret/test"}))
+	assert.NoError(t, policy.CheckPassword(&UserPass{Username: "test", Password: "password1", PasswordExemption: "test fixture"}))
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"sort"
+	"strings"
+)
+
+var (
+	// backendRegistry is the backend types known to vaultctl, along with the schemas of their uris
+	backendRegistry = map[string][]*uriSchema{
+		"aws":        awsSchema,
+		"cassandra":  nil,
+		"consul":     consulSchema,
+		"cubbyhole":  nil,
+		"custom":     nil,
+		"database":   nil,
+		"generic":    nil,
+		"kv":         nil,
+		"mongodb":    nil,
+		"mssql":      nil,
+		"mysql":      mysqlSchema,
+		"pki":        pkiSchema,
+		"postgres":   postgresSchema,
+		"postgresql": postgresSchema,
+		"rabbitmq":   nil,
+		"ssh":        sshSchema,
+		"totp":       nil,
+		"transit":    transitSchema,
+	}
+
+	// authRegistry is the auth types known to vaultctl, along with the user types which can be managed in them
+	authRegistry = map[string][]string{
+		"app-id":   {AppIDType, UserIDType},
+		"appid":    {AppIDType, UserIDType},
+		"approle":  nil,
+		"aws-ec2":  nil,
+		"cert":     {CertType},
+		"github":   {GithubUserType, GithubTeamType},
+		"ldap":     {LDAPUserType, LDAPGroupType},
+		"mfa":      nil,
+		"okta":     nil,
+		"radius":   nil,
+		"tls":      {CertType},
+		"token":    nil,
+		"userpass": {UserPassType},
+	}
+)
+
+// IsKnownBackendType checks if the backend type is in the registry
+func IsKnownBackendType(name string) bool {
+	_, found := backendRegistry[name]
+	return found
+}
+
+// IsKnownAuthType checks if the auth type is in the registry
+func IsKnownAuthType(name string) bool {
+	_, found := authRegistry[name]
+	return found
+}
+
+// supportedBackends returns a list of supported backend types
+func supportedBackends() string {
+	var list []string
+	for k := range backendRegistry {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+
+	return strings.Join(list, ",")
+}
+
+// supportedAuths returns a list of supported auth types
+func supportedAuths() string {
+	var list []string
+	for k := range authRegistry {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+
+	return strings.Join(list, ",")
+}
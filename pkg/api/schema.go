@@ -0,0 +1,278 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	stringField = iota
+	boolField
+	intField
+	durationField
+	listField
+	// anyField is a field whose value is not checked, i.e. a map
+	anyField
+)
+
+// fields is a map of field names to the type of value
+type fields map[string]int
+
+// uriSchema is the definition of the fields permitted on a uri of a backend
+type uriSchema struct {
+	// the uri pattern, i.e. roles/*
+	pattern string
+	// the fields which must be present
+	required fields
+	// the fields which may be present
+	optional fields
+}
+
+var (
+	// pkiSubjectFields are the optional subject fields of a certificate
+	pkiSubjectFields = fields{
+		"ou": listField, "organization": listField, "country": listField, "locality": listField,
+		"province": listField, "street_address": listField, "postal_code": listField,
+	}
+
+	// pkiCertFields are the optional fields when generating or signing a certificate
+	pkiCertFields = mergeFields(pkiSubjectFields, fields{
+		"alt_names": listField, "ip_sans": listField, "uri_sans": listField, "other_sans": listField,
+		"ttl": durationField, "format": stringField, "private_key_format": stringField,
+		"key_type": stringField, "key_bits": intField, "exclude_cn_from_sans": boolField,
+		"max_path_length": intField, "permitted_dns_domains": listField, "serial_number": stringField,
+		"use_csr_values": boolField,
+	})
+
+	// pkiSchema are the known uris for the pki backend
+	pkiSchema = []*uriSchema{
+		{pattern: "config/ca", required: fields{"pem_bundle": stringField}},
+		{pattern: "config/urls", optional: fields{
+			"issuing_certificates": listField, "crl_distribution_points": listField, "ocsp_servers": listField,
+		}},
+		{pattern: "config/crl", optional: fields{"expiry": durationField, "disable": boolField}},
+		{pattern: "root/generate/*", required: fields{"common_name": stringField}, optional: pkiCertFields},
+		{pattern: "intermediate/generate/*", required: fields{"common_name": stringField}, optional: pkiCertFields},
+		{pattern: "intermediate/set-signed", required: fields{"certificate": stringField}},
+		{pattern: "root/sign-intermediate", required: fields{"csr": stringField, "common_name": stringField}, optional: pkiCertFields},
+		{pattern: "roles/*", optional: mergeFields(pkiSubjectFields, fields{
+			"ttl": durationField, "max_ttl": durationField, "lease": durationField, "lease_max": durationField,
+			"allow_localhost": boolField, "allowed_domains": listField, "allowed_domains_template": boolField,
+			"allow_bare_domains": boolField, "allow_subdomains": boolField, "allow_glob_domains": boolField,
+			"allow_any_name": boolField, "enforce_hostnames": boolField, "allow_ip_sans": boolField,
+			"allowed_uri_sans": listField, "allowed_other_sans": listField, "allowed_serial_numbers": listField,
+			"server_flag": boolField, "client_flag": boolField, "code_signing_flag": boolField,
+			"email_protection_flag": boolField, "key_type": stringField, "key_bits": intField,
+			"key_usage": listField, "ext_key_usage": listField, "ext_key_usage_oids": listField,
+			"use_csr_common_name": boolField, "use_csr_sans": boolField, "generate_lease": boolField,
+			"no_store": boolField, "require_cn": boolField, "policy_identifiers": listField,
+			"basic_constraints_valid_for_non_ca": boolField, "not_before_duration": durationField,
+		})},
+	}
+
+	// transitSchema are the known uris for the transit backend
+	transitSchema = []*uriSchema{
+		{pattern: "keys/*", optional: fields{
+			"derived": boolField, "convergent_encryption": boolField, "type": stringField,
+			"exportable": boolField, "allow_plaintext_backup": boolField,
+		}},
+		{pattern: "keys/*/config", optional: fields{
+			"min_decryption_version": intField, "min_encryption_version": intField, "deletion_allowed": boolField,
+			"exportable": boolField, "allow_plaintext_backup": boolField,
+		}},
+		{pattern: "keys/*/rotate"},
+	}
+
+	// awsSchema are the known uris for the aws backend
+	awsSchema = []*uriSchema{
+		{pattern: "config/root", optional: fields{
+			"access_key": stringField, "secret_key": stringField, "region": stringField,
+			"iam_endpoint": stringField, "sts_endpoint": stringField, "max_retries": intField,
+		}},
+		{pattern: "config/lease", required: fields{"lease": durationField, "lease_max": durationField}},
+		{pattern: "roles/*", optional: fields{
+			"policy": stringField, "arn": stringField, "policy_document": stringField, "policy_arns": listField,
+			"role_arns": listField, "credential_type": stringField, "default_sts_ttl": durationField,
+			"max_sts_ttl": durationField, "iam_groups": listField, "user_path": stringField,
+		}},
+	}
+
+	// sqlConnectionFields are the fields of the connection of the sql backends
+	sqlConnectionFields = fields{
+		"connection_url": stringField, "value": stringField, "max_open_connections": intField,
+		"max_idle_connections": intField, "verify_connection": boolField,
+	}
+
+	// mysqlSchema are the known uris for the mysql backend
+	mysqlSchema = []*uriSchema{
+		{pattern: "config/connection", optional: sqlConnectionFields},
+		{pattern: "config/lease", required: fields{"lease": durationField, "lease_max": durationField}},
+		{pattern: "roles/*", required: fields{"sql": stringField}, optional: fields{
+			"revocation_sql": stringField, "username_length": intField, "displayname_length": intField,
+			"rolename_length": intField,
+		}},
+	}
+
+	// postgresSchema are the known uris for the postgres backend
+	postgresSchema = []*uriSchema{
+		{pattern: "config/connection", optional: sqlConnectionFields},
+		{pattern: "config/lease", required: fields{"lease": durationField, "lease_max": durationField}},
+		{pattern: "roles/*", required: fields{"sql": stringField}, optional: fields{"revocation_sql": stringField}},
+	}
+
+	// sshSchema are the known uris for the ssh backend
+	sshSchema = []*uriSchema{
+		{pattern: "keys/*", required: fields{"key": stringField}},
+		{pattern: "config/zeroaddress", required: fields{"roles": listField}},
+		{pattern: "roles/*", required: fields{"key_type": stringField}, optional: fields{
+			"key": stringField, "admin_user": stringField, "default_user": stringField,
+			"cidr_list": listField, "exclude_cidr_list": listField, "port": intField,
+			"key_bits": intField, "install_script": stringField, "allowed_users": listField,
+			"allowed_domains": listField, "key_option_specs": stringField, "ttl": durationField,
+			"max_ttl": durationField, "allowed_critical_options": listField, "allowed_extensions": listField,
+			"default_critical_options": anyField, "default_extensions": anyField,
+			"allow_user_certificates": boolField, "allow_host_certificates": boolField,
+			"allow_bare_domains": boolField, "allow_subdomains": boolField, "allow_user_key_ids": boolField,
+			"key_id_format": stringField, "allowed_user_key_lengths": anyField,
+		}},
+	}
+
+	// consulSchema are the known uris for the consul backend
+	consulSchema = []*uriSchema{
+		{pattern: "config/access", required: fields{"address": stringField}, optional: fields{
+			"token": stringField, "scheme": stringField, "ca_cert": stringField, "client_cert": stringField,
+			"client_key": stringField,
+		}},
+		{pattern: "roles/*", optional: fields{
+			"policy": stringField, "policies": listField, "lease": durationField, "token_type": stringField,
+			"local": boolField, "ttl": durationField, "max_ttl": durationField,
+		}},
+	}
+)
+
+// mergeFields returns a set of fields containing all the fields
+func mergeFields(sets ...fields) fields {
+	merged := make(fields, 0)
+	for _, x := range sets {
+		for k, v := range x {
+			merged[k] = v
+		}
+	}
+
+	return merged
+}
+
+// getURISchema retrieves the schema for a uri on a backend type, returning nil if the uri is unknown
+func getURISchema(backendType, uri string) *uriSchema {
+	for _, x := range backendRegistry[backendType] {
+		if matched, _ := path.Match(x.pattern, strings.Trim(uri, "/")); matched {
+			return x
+		}
+	}
+
+	return nil
+}
+
+// validate checks the attributes against the schema
+func (r *uriSchema) validate(attrs *Attributes) error {
+	settings := attrs.Settings()
+	for name := range r.required {
+		if _, found := settings[name]; !found {
+			return fmt.Errorf("missing required field: %s", name)
+		}
+	}
+
+	// step: sort the keys so the errors are consistent
+	var keys []string
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		kind, found := r.required[k]
+		if !found {
+			if kind, found = r.optional[k]; !found {
+				return fmt.Errorf("unknown field: %s", k)
+			}
+		}
+		if err := validateFieldType(kind, settings[k]); err != nil {
+			return fmt.Errorf("field: %s, %s", k, err)
+		}
+	}
+
+	return nil
+}
+
+// validateFieldType checks the value is of the expected type
+func validateFieldType(kind int, value interface{}) error {
+	switch kind {
+	case boolField:
+		switch v := value.(type) {
+		case bool:
+			return nil
+		case string:
+			if _, err := strconv.ParseBool(v); err == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("value: %v must be a boolean", value)
+	case intField:
+		switch v := value.(type) {
+		case int, int64, float64:
+			return nil
+		case string:
+			if _, err := strconv.Atoi(v); err == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("value: %v must be an integer", value)
+	case durationField:
+		switch v := value.(type) {
+		case int, int64, float64:
+			return nil
+		case string:
+			if _, err := time.ParseDuration(v); err == nil {
+				return nil
+			}
+			if _, err := strconv.Atoi(v); err == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("value: %v must be a duration, i.e. 1h or seconds", value)
+	case listField:
+		switch value.(type) {
+		case string, []interface{}, []string:
+			return nil
+		}
+		return fmt.Errorf("value: %v must be a list or a comma separated string", value)
+	case anyField:
+		return nil
+	default:
+		switch value.(type) {
+		case map[interface{}]interface{}, map[string]interface{}, []interface{}:
+			return fmt.Errorf("value must be a string")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetURISchema(t *testing.T) {
+	assert.NotNil(t, getURISchema("pki", "roles/example-dot-com"))
+	assert.NotNil(t, getURISchema("pki", "/root/generate/internal"))
+	assert.NotNil(t, getURISchema("transit", "keys/default/config"))
+	assert.Nil(t, getURISchema("pki", "roles/example/extra"))
+	assert.Nil(t, getURISchema("generic", "anything"))
+}
+
+func TestBackendSchemaValidation(t *testing.T) {
+	tests := []struct {
+		Type  string
+		Attrs *Attributes
+		Ok    bool
+	}{
+		{
+			Type:  "pki",
+			Attrs: &Attributes{"uri": "roles/test", "allowed_domains": "example.com", "max_ttl": "1h", "allow_subdomains": true},
+			Ok:    true,
+		},
+		{
+			Type:  "pki",
+			Attrs: &Attributes{"uri": "roles/test", "allowed_domain": "example.com"},
+		},
+		{
+			Type:  "pki",
+			Attrs: &Attributes{"uri": "roles/test", "max_ttl": "one hour"},
+		},
+		{
+			Type:  "pki",
+			Attrs: &Attributes{"uri": "roles/test", "allow_subdomains": "maybe"},
+		},
+		{
+			Type:  "pki",
+			Attrs: &Attributes{"uri": "root/generate/internal", "ttl": "3h", "oneshot": true},
+		},
+		{
+			Type:  "pki",
+			Attrs: &Attributes{"uri": "root/generate/internal", "common_name": "example.com", "ttl": "3h", "oneshot": true},
+			Ok:    true,
+		},
+		{
+			Type:  "pki",
+			Attrs: &Attributes{"uri": "roles/test", "allowed_domain": "example.com", "raw": true},
+			Ok:    true,
+		},
+		{
+			Type:  "pki",
+			Attrs: &Attributes{"uri": "some/new/endpoint", "anything": "goes"},
+			Ok:    true,
+		},
+		{
+			Type:  "aws",
+			Attrs: &Attributes{"uri": "config/lease", "lease": "1h"},
+		},
+		{
+			Type:  "mysql",
+			Attrs: &Attributes{"uri": "roles/readonly", "sql": "SELECT 1", "username_length": 10},
+			Ok:    true,
+		},
+		{
+			Type:  "transit",
+			Attrs: &Attributes{"uri": "keys/default/config", "min_decryption_version": "one"},
+		},
+		{
+			Type: "pki",
+			Attrs: &Attributes{
+				"uri": "roles/example-dot-com", "ttl": "72h", "max_ttl": "720h", "allow_localhost": false,
+				"allowed_domains": []interface{}{"example.com", "*.example.com"}, "allow_bare_domains": true,
+				"allow_subdomains": true, "allow_glob_domains": true, "allow_any_name": false,
+				"enforce_hostnames": true, "allow_ip_sans": true, "server_flag": true, "client_flag": false,
+				"code_signing_flag": false, "email_protection_flag": false, "key_type": "rsa", "key_bits": 2048,
+				"key_usage": "DigitalSignature,KeyEncipherment", "use_csr_common_name": true, "use_csr_sans": true,
+				"ou": "platform", "organization": "Home Office", "country": "GB", "generate_lease": false,
+				"no_store": true, "lease": "72h", "require_cn": true,
+			},
+			Ok: true,
+		},
+		{
+			Type:  "pki",
+			Attrs: &Attributes{"uri": "root/generate/internal", "common_name": "example.com", "ou": "platform", "organization": "Home Office", "oneshot": true},
+			Ok:    true,
+		},
+		{
+			Type:  "aws",
+			Attrs: &Attributes{"uri": "config/root", "region": "eu-west-2"},
+			Ok:    true,
+		},
+		{
+			Type: "ssh",
+			Attrs: &Attributes{"uri": "roles/otp", "key_type": "ca", "allow_user_certificates": true,
+				"default_extensions": map[string]interface{}{"permit-pty": ""}, "ttl": "30m"},
+			Ok: true,
+		},
+		{
+			Type:  "consul",
+			Attrs: &Attributes{"uri": "config/access", "address": "127.0.0.1:8500"},
+			Ok:    true,
+		},
+	}
+
+	for i, c := range tests {
+		backend := &Backend{Path: "test", Description: "test", Type: c.Type, Attrs: []*Attributes{c.Attrs}}
+		err := backend.IsValid()
+		if !c.Ok {
+			assert.Error(t, err, "case %d should have errored", i)
+		} else {
+			assert.NoError(t, err, "case %d should have not errored", i)
+		}
+	}
+}
@@ -15,11 +15,79 @@ limitations under the License.
 
 package api
 
-import "time"
+import (
+	"os"
+	"regexp"
+	"time"
+)
+
+const (
+	// UserPassType is a user in a userpass backend
+	UserPassType = "userpass"
+	// UserTokenType is a token in the token backend
+	UserTokenType = "usertoken"
+	// LDAPUserType is a user in a ldap backend
+	LDAPUserType = "ldapuser"
+	// LDAPGroupType is a group in a ldap backend
+	LDAPGroupType = "ldapgroup"
+	// GithubUserType is a user mapping in a github backend
+	GithubUserType = "githubuser"
+	// GithubTeamType is a team mapping in a github backend
+	GithubTeamType = "githubteam"
+	// AppIDType is a app-id mapping in a app-id backend
+	AppIDType = "appid"
+	// UserIDType is a user-id mapping in a app-id backend
+	UserIDType = "userid"
+	// CertType is a trusted certificate in a cert backend
+	CertType = "cert"
+)
+
+var (
+	// userCollections is the collection under the auth backend each user type lives under
+	userCollections = map[string]string{
+		UserPassType:   "users",
+		LDAPUserType:   "users",
+		LDAPGroupType:  "groups",
+		GithubUserType: "map/users",
+		GithubTeamType: "map/teams",
+		AppIDType:      "map/app-id",
+		UserIDType:     "map/user-id",
+		CertType:       "certs",
+	}
+
+	// reservedAttributes are the attribute fields used by vaultctl and not passed to vault
+	reservedAttributes = []string{"uri", "oneshot", "raw", "outputs"}
+	// variableRegex is the format of a reference to a variable in an attribute value i.e. ${name}
+	variableRegex = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_]*)\}`)
+	// variableNameRegex is the format of a variable name
+	variableNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
 
 // Attributes is a map of configuration
 type Attributes map[string]interface{}
 
+// Output extracts a field from the response of an attribute
+type Output struct {
+	// Path is the json path of the field in the response i.e. data.certificate
+	Path string `yaml:"path" json:"path" hcl:"path"`
+	// File is a file to write the value to
+	File string `yaml:"file" json:"file" hcl:"file"`
+	// Mode is the file mode of the file, defaults to 0600
+	Mode os.FileMode `yaml:"mode" json:"mode" hcl:"mode"`
+	// VaultPath is a secret path in vault to write the value to
+	VaultPath string `yaml:"vault-path" json:"vault-path" hcl:"vault-path"`
+	// Key is the key in the vault secret, defaults to the last element of the json path
+	Key string `yaml:"key" json:"key" hcl:"key"`
+	// Variable is the name of a variable exposing the value to later attributes as ${name}
+	Variable string `yaml:"variable" json:"variable" hcl:"variable"`
+}
+
+// ValidationOptions are the options applied when validating the resources
+type ValidationOptions struct {
+	// AllowUnknownTypes permits backend and auth types unknown to the registry, relying on vault to accept them
+	AllowUnknownTypes bool
+}
+
 // Config is the definition for a config file
 type Config struct {
 	// Users is a series of users
@@ -32,6 +100,42 @@ type Config struct {
 	Auths []*Auth `yaml:"auths" json:"auths" hcl:"auths"`
 	// Policies is a series of policies
 	Policies []*Policy `yaml:"policies" json:"policies" hcl:"policies"`
+	// Targets is a series of vault clusters to apply the config to
+	Targets []*Target `yaml:"targets" json:"targets" hcl:"targets"`
+	// PasswordPolicy is the policy applied to the passwords of userpass users
+	PasswordPolicy *PasswordPolicy `yaml:"password-policy" json:"password-policy" hcl:"password-policy"`
+}
+
+// PasswordPolicy defines the requirements for the passwords of userpass users
+type PasswordPolicy struct {
+	// MinLength is the minimum length of a password
+	MinLength int `yaml:"min-length" json:"min-length" hcl:"min-length"`
+	// RequireUpper indicates the password must contain an uppercase letter
+	RequireUpper bool `yaml:"require-upper" json:"require-upper" hcl:"require-upper"`
+	// RequireLower indicates the password must contain a lowercase letter
+	RequireLower bool `yaml:"require-lower" json:"require-lower" hcl:"require-lower"`
+	// RequireDigit indicates the password must contain a digit
+	RequireDigit bool `yaml:"require-digit" json:"require-digit" hcl:"require-digit"`
+	// RequireSymbol indicates the password must contain a symbol
+	RequireSymbol bool `yaml:"require-symbol" json:"require-symbol" hcl:"require-symbol"`
+	// DenyList is a list of passwords which are not permitted, in addition to the common passwords
+	DenyList []string `yaml:"deny-list" json:"deny-list" hcl:"deny-list"`
+}
+
+// Target is a vault cluster the resources should be applied to
+type Target struct {
+	// Name is the name of the target, used to select it on the command line
+	Name string `yaml:"name" json:"name" hcl:"name"`
+	// Address is the url address of the vault service
+	Address string `yaml:"address" json:"address" hcl:"address"`
+	// Token is a vault token used to authenticate to the vault service
+	Token string `yaml:"token" json:"token" hcl:"token"`
+	// Username is the username used to authenticate to the vault service
+	Username string `yaml:"username" json:"username" hcl:"username"`
+	// Password is the password used to authenticate to the vault service
+	Password string `yaml:"password" json:"password" hcl:"password"`
+	// Credentials is the path to a file containing the userpass credentials
+	Credentials string `yaml:"credentials" json:"credentials" hcl:"credentials"`
 }
 
 // Auth defined a authentication backend
@@ -44,6 +148,8 @@ type Auth struct {
 	Description string `yaml:"description" json:"description" hcl:"description"`
 	// Attributes is a map of configurations for the backend
 	Attrs []*Attributes `yaml:"attributes" json:"attributes" hcl:"attributes"`
+	// State is the desired state of the auth backend, present (the default) or absent to remove it
+	State string `yaml:"state" json:"state" hcl:"state"`
 }
 
 // Backend defined the type and configuration for a backend in vault
@@ -60,6 +166,56 @@ type Backend struct {
 	MaxLeaseTTL time.Duration `yaml:"max-lease-ttl" json:"max-lease-ttl" hcl:"max-lease-ttl"`
 	// Attrs is the configuration of the mount point
 	Attrs []*Attributes `yaml:"attributes" json:"attributes" hcl:"attributes"`
+	// MovedFrom is the previous path of the backend, the mount is moved rather than recreated
+	MovedFrom string `yaml:"moved_from" json:"moved_from" hcl:"moved_from"`
+	// Managed is a list of collections under the backend, i.e. roles, which are pruned on a full sync
+	Managed []string `yaml:"managed" json:"managed" hcl:"managed"`
+	// PKI is the certificate authority definition for a pki backend
+	PKI *PKI `yaml:"pki" json:"pki" hcl:"pki"`
+	// Keys is a list of keys managed in a transit backend
+	Keys []*TransitKey `yaml:"keys" json:"keys" hcl:"keys"`
+	// State is the desired state of the backend, present (the default) or absent to remove it
+	State string `yaml:"state" json:"state" hcl:"state"`
+}
+
+// TransitKey defines a named encryption key in a transit backend
+type TransitKey struct {
+	// Name is the name of the key
+	Name string `yaml:"name" json:"name" hcl:"name"`
+	// Derived indicates the key supports key derivation, this can only be set on creation
+	Derived bool `yaml:"derived" json:"derived" hcl:"derived"`
+	// DeletionAllowed indicates the key is permitted to be deleted
+	DeletionAllowed bool `yaml:"deletion-allowed" json:"deletion-allowed" hcl:"deletion-allowed"`
+	// MinDecryptionVersion is the minimum version of the key permitted to decrypt
+	MinDecryptionVersion int `yaml:"min-decryption-version" json:"min-decryption-version" hcl:"min-decryption-version"`
+	// RotateEvery is the age of the latest version of the key after which it is rotated
+	RotateEvery time.Duration `yaml:"rotate-every" json:"rotate-every" hcl:"rotate-every"`
+}
+
+// PKI defines the certificate authority of a pki backend within a hierarchy
+type PKI struct {
+	// Root is the definition of a root certificate authority
+	Root *CertificateAuthority `yaml:"root" json:"root" hcl:"root"`
+	// Intermediate is the definition of an intermediate certificate authority
+	Intermediate *CertificateAuthority `yaml:"intermediate" json:"intermediate" hcl:"intermediate"`
+	// ExportFile is the path of a file to export the ca chain to
+	ExportFile string `yaml:"export-file" json:"export-file" hcl:"export-file"`
+	// ExportPath is the vault path to export the ca chain to
+	ExportPath string `yaml:"export-path" json:"export-path" hcl:"export-path"`
+}
+
+// CertificateAuthority is the definition of a certificate authority
+type CertificateAuthority struct {
+	// CommonName is the common name of the certificate authority
+	CommonName string `yaml:"common-name" json:"common-name" hcl:"common-name"`
+	// TTL is the time to live of the certificate authority
+	TTL time.Duration `yaml:"ttl" json:"ttl" hcl:"ttl"`
+	// KeyType is the type of key, rsa or ec
+	KeyType string `yaml:"key-type" json:"key-type" hcl:"key-type"`
+	// KeyBits is the number of bits of the key
+	KeyBits int `yaml:"key-bits" json:"key-bits" hcl:"key-bits"`
+	// SignedBy is the path of the pki backend which signs an intermediate
+	SignedBy string `yaml:"signed-by" json:"signed-by" hcl:"signed-by"`
 }
 
 // Policy defines a vault policy
@@ -68,6 +224,8 @@ type Policy struct {
 	Name string `yaml:"name" json:"name" hcl:"name"`
 	// Policy is the policy itsefl
 	Policy string `yaml:"policy" json:"policy" hcl:"policy"`
+	// State is the desired state of the policy, present (the default) or absent to remove it
+	State string `yaml:"state" json:"state" hcl:"state"`
 }
 
 // Secret defines a secret
@@ -76,6 +234,56 @@ type Secret struct {
 	Path string `yaml:"path" json:"path" hcl:"path"`
 	// Values is a series of values associated to the secret
 	Values map[string]interface{} `yaml:"values" json:"values" hcl:"values"`
+	// Files is a series of values read from files, keyed by the secret key
+	Files map[string]*SecretFile `yaml:"files,omitempty" json:"files,omitempty" hcl:"files"`
+	// Mode is how the values are applied, replace (the default) or merge with the keys in vault
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty" hcl:"mode"`
+	// ManagedKeys is a list of keys owned by the config in merge mode, removed when not declared
+	ManagedKeys []string `yaml:"managed_keys,omitempty" json:"managed_keys,omitempty" hcl:"managed_keys"`
+	// State is the desired state of the secret, present (the default) or absent to remove it
+	State string `yaml:"state,omitempty" json:"state,omitempty" hcl:"state"`
+}
+
+// Generator defines a secret value generated by vaultctl, rather than declared in the config
+type Generator struct {
+	// Type is the type of value, random, hex or uuid
+	Type string `yaml:"type" json:"type" hcl:"type"`
+	// Length is the length of a random or hex value
+	Length int `yaml:"length" json:"length" hcl:"length"`
+	// Charset is the named charset of a random value, alnum, alpha, numeric or password
+	Charset string `yaml:"charset" json:"charset" hcl:"charset"`
+}
+
+// SecretFile is a secret value read from a file
+type SecretFile struct {
+	// Path is the path to the file, relative to the config file
+	Path string `yaml:"path" json:"path" hcl:"path"`
+	// Base64 indicates the content should be base64 encoded, i.e. binary files
+	Base64 bool `yaml:"base64" json:"base64" hcl:"base64"`
+}
+
+const (
+	// PresentState indicates the resource should exist
+	PresentState = "present"
+	// AbsentState indicates the resource should be removed
+	AbsentState = "absent"
+)
+
+const (
+	// ReplaceMode replaces the entire secret with the declared values
+	ReplaceMode = "replace"
+	// MergeMode reconciles the declared keys, preserving any others
+	MergeMode = "merge"
+)
+
+// SecretDiff is the difference between a declared secret and the values in vault
+type SecretDiff struct {
+	// Added is the keys not present in vault
+	Added []string
+	// Changed is the keys whose values differ from vault
+	Changed []string
+	// Removed is the keys in vault no longer declared
+	Removed []string
 }
 
 // User is the definition for a user
@@ -86,18 +294,40 @@ type User struct {
 	UserPass *UserPass `yaml:"userpass" json:"userpass" hcl:"userpass"`
 	// UserToken is a token struct for this user
 	UserToken *UserToken `yaml:"usertoken" json:"usertoken" hcl:"usertoken"`
+	// LDAPUser is a user mapping in a ldap auth backend
+	LDAPUser *LDAPUser `yaml:"ldapuser" json:"ldapuser" hcl:"ldapuser"`
+	// LDAPGroup is a group mapping in a ldap auth backend
+	LDAPGroup *LDAPGroup `yaml:"ldapgroup" json:"ldapgroup" hcl:"ldapgroup"`
+	// GithubUser is a user mapping in a github auth backend
+	GithubUser *GithubUser `yaml:"githubuser" json:"githubuser" hcl:"githubuser"`
+	// GithubTeam is a team mapping in a github auth backend
+	GithubTeam *GithubTeam `yaml:"githubteam" json:"githubteam" hcl:"githubteam"`
+	// AppID is a app-id mapping in a app-id auth backend
+	AppID *AppID `yaml:"appid" json:"appid" hcl:"appid"`
+	// UserID is a user-id mapping in a app-id auth backend
+	UserID *UserID `yaml:"userid" json:"userid" hcl:"userid"`
+	// Cert is a trusted certificate in a cert auth backend
+	Cert *Cert `yaml:"cert" json:"cert" hcl:"cert"`
 	// Policies is a list of policies the user has access to
 	Policies []string `yaml:"policies" json:"policies" hcl:"policies"`
 	// Namespace is optional and used when adding to kubernetes
 	Namespace string `yaml:"namespace" json:"namespace" hcl:"namespace"`
+	// State is the desired state of the user, present (the default) or absent to remove it
+	State string `yaml:"state" json:"state" hcl:"state"`
 }
 
 // UserCredentials are the userpass credentials
 type UserPass struct {
 	// Username is the id of the user
 	Username string `yaml:"username" json:"username" hcl:"username"`
-	// Password is the password of the user
+	// Password is the password of the user, if empty a password is generated on creation
 	Password string `yaml:"password" json:"password" hcl:"password"`
+	// PasswordPath is the vault path to store a generated password in
+	PasswordPath string `yaml:"password-path" json:"password-path" hcl:"password-path"`
+	// PasswordFile is the file to write a generated password to
+	PasswordFile string `yaml:"password-file" json:"password-file" hcl:"password-file"`
+	// PasswordExemption is the reason the password is exempt from the password policy
+	PasswordExemption string `yaml:"password-exemption" json:"password-exemption" hcl:"password-exemption"`
 }
 
 // UserToken is the token
@@ -110,4 +340,64 @@ type UserToken struct {
 	DisplayName string `yaml:"display-name" json:"display-name" hcl:"display-name"`
 	// MaxUses is the max number of times the token can be used
 	MaxUses int `yaml:"max-uses" json:"max-uses" hcl:"max-uses"`
+	// TokenPath is a vault path used to store the token and accessor of a created token
+	TokenPath string `yaml:"token-path" json:"token-path" hcl:"token-path"`
+	// RenewThreshold is the remaining ttl under which the token is renewed, defaults to half the ttl
+	RenewThreshold time.Duration `yaml:"renew-threshold" json:"renew-threshold" hcl:"renew-threshold"`
+}
+
+// LDAPUser is a user in the ldap backend
+type LDAPUser struct {
+	// Username is the ldap username
+	Username string `yaml:"username" json:"username" hcl:"username"`
+	// Groups is a list of ldap groups the user is a member of
+	Groups []string `yaml:"groups" json:"groups" hcl:"groups"`
+}
+
+// LDAPGroup is a group in the ldap backend
+type LDAPGroup struct {
+	// Name is the name of the ldap group
+	Name string `yaml:"name" json:"name" hcl:"name"`
+}
+
+// GithubUser maps a github user to policies
+type GithubUser struct {
+	// Username is the github username
+	Username string `yaml:"username" json:"username" hcl:"username"`
+}
+
+// GithubTeam maps a github team to policies
+type GithubTeam struct {
+	// Name is the name of the github team
+	Name string `yaml:"name" json:"name" hcl:"name"`
+}
+
+// AppID maps a app-id to policies
+type AppID struct {
+	// ID is the app id
+	ID string `yaml:"id" json:"id" hcl:"id"`
+	// DisplayName is a display name for the app
+	DisplayName string `yaml:"display-name" json:"display-name" hcl:"display-name"`
+}
+
+// UserID maps a user-id to one or more app-ids
+type UserID struct {
+	// ID is the user id
+	ID string `yaml:"id" json:"id" hcl:"id"`
+	// AppIDs is a list of app-ids the user id is associated to
+	AppIDs []string `yaml:"app-ids" json:"app-ids" hcl:"app-ids"`
+	// CIDRBlock is an optional cidr the user id is restricted to
+	CIDRBlock string `yaml:"cidr-block" json:"cidr-block" hcl:"cidr-block"`
+}
+
+// Cert is a trusted certificate in the cert backend
+type Cert struct {
+	// Name is the name of the certificate
+	Name string `yaml:"name" json:"name" hcl:"name"`
+	// Certificate is the path to the PEM encoded certificate
+	Certificate string `yaml:"certificate" json:"certificate" hcl:"certificate"`
+	// DisplayName is a display name for the certificate
+	DisplayName string `yaml:"display-name" json:"display-name" hcl:"display-name"`
+	// TTL is the ttl of the tokens issued
+	TTL time.Duration `yaml:"ttl" json:"ttl" hcl:"ttl"`
 }
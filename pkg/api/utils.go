@@ -16,8 +16,18 @@ limitations under the License.
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/utils"
+	"gopkg.in/yaml.v2"
 )
 
 // String returns a string representation of the backend
@@ -39,6 +49,53 @@ func (r Backend) GetPath() string {
 	return fmt.Sprintf("%s", strings.TrimPrefix(strings.TrimSuffix(r.Path, "/"), "/"))
 }
 
+// GetMovedFrom returns the previous path of the backend
+func (r Backend) GetMovedFrom() string {
+	return strings.TrimPrefix(strings.TrimSuffix(r.MovedFrom, "/"), "/")
+}
+
+// GetManagedItems returns the names of the items declared in the attributes under a collection
+func (r Backend) GetManagedItems(collection string) []string {
+	var list []string
+	prefix := strings.Trim(collection, "/") + "/"
+	for _, x := range r.Attrs {
+		uri := strings.Trim(x.URI(), "/")
+		if !strings.HasPrefix(uri, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(uri, prefix)
+		if name != "" && !strings.Contains(name, "/") {
+			list = append(list, name)
+		}
+	}
+	// step: the transit keys are declared in the keys collection
+	if prefix == "keys/" {
+		for _, x := range r.Keys {
+			list = append(list, x.Name)
+		}
+	}
+
+	return list
+}
+
+// GetCA returns the definition of the certificate authority
+func (r PKI) GetCA() *CertificateAuthority {
+	if r.Root != nil {
+		return r.Root
+	}
+
+	return r.Intermediate
+}
+
+// GetSigner returns the path of the backend which signs the certificate authority, empty for a root
+func (r PKI) GetSigner() string {
+	if r.Intermediate != nil {
+		return strings.Trim(r.Intermediate.SignedBy, "/")
+	}
+
+	return ""
+}
+
 // GetMaxTTL returns the max ttl
 func (r Backend) GetMaxTTL() string {
 	if r.MaxLeaseTTL <= 0 {
@@ -63,11 +120,189 @@ func (r *Attributes) IsOneshot() bool {
 	return found
 }
 
+// IsRaw checks if the attribute should skip the schema validation
+func (r *Attributes) IsRaw() bool {
+	_, found := (*r)["raw"]
+	return found
+}
+
 // Values retrieves the values from the attributes
 func (r *Attributes) Values() map[string]interface{} {
 	return (*r)
 }
 
+// Settings returns the values of the attributes, excluding the fields used by vaultctl itself
+func (r *Attributes) Settings() map[string]interface{} {
+	settings := make(map[string]interface{}, 0)
+	for k, v := range *r {
+		if utils.ContainedIn(k, reservedAttributes) {
+			continue
+		}
+		settings[k] = v
+	}
+
+	return settings
+}
+
+// Hash returns a hash of the attributes under a namespace, used to track the execution of oneshots
+func (r *Attributes) Hash(ns string) string {
+	var keys []string
+	for k := range *r {
+		if k == "oneshot" || k == "outputs" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	hash.Write([]byte(ns))
+	for _, k := range keys {
+		hash.Write([]byte(fmt.Sprintf("|%s=%v", k, (*r)[k])))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// GetValues returns the values of the secret, including the content of any files
+func (r *Secret) GetValues() (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(r.Values)+len(r.Files))
+	for k, v := range r.Values {
+		values[k] = v
+	}
+	for k, x := range r.Files {
+		content, err := ioutil.ReadFile(x.Path)
+		if err != nil {
+			return nil, fmt.Errorf("secret: %s, key: %s, unable to read the file, error: %s", r.Path, k, err)
+		}
+		if x.Base64 {
+			values[k] = base64.StdEncoding.EncodeToString(content)
+			continue
+		}
+		values[k] = string(content)
+	}
+
+	return values, nil
+}
+
+// Reconcile returns the values to be written given the resolved values of the secret and the current
+// values in vault; in merge mode the keys in vault which are not declared are preserved, unless they
+// are managed keys
+func (r *Secret) Reconcile(values, current map[string]interface{}) map[string]interface{} {
+	if r.Mode != MergeMode {
+		return values
+	}
+	merged := make(map[string]interface{}, len(current)+len(values))
+	for k, v := range current {
+		if utils.ContainedIn(k, r.ManagedKeys) {
+			continue
+		}
+		merged[k] = v
+	}
+	for k, v := range values {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// Diff compares the values of the secret against the current values in vault, key by key
+func (r *Secret) Diff(current map[string]interface{}) *SecretDiff {
+	diff := &SecretDiff{}
+	for k, v := range r.Values {
+		value, found := current[k]
+		switch {
+		case !found:
+			diff.Added = append(diff.Added, k)
+		case fmt.Sprintf("%v", v) != fmt.Sprintf("%v", value):
+			diff.Changed = append(diff.Changed, k)
+		}
+	}
+	for k := range current {
+		if _, found := r.Values[k]; !found {
+			diff.Removed = append(diff.Removed, k)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Changed)
+	sort.Strings(diff.Removed)
+
+	return diff
+}
+
+// HasChanged checks if there are any differences
+func (r *SecretDiff) HasChanged() bool {
+	return len(r.Added) > 0 || len(r.Changed) > 0 || len(r.Removed) > 0
+}
+
+// HashValue returns a short hash of a secret value, permitting a change to be shown without the value
+func HashValue(value interface{}) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%v", value)))
+
+	return "sha256:" + hex.EncodeToString(hash[:])[:12]
+}
+
+// GetOutputs returns the outputs extracted from the response of the attributes
+func (r *Attributes) GetOutputs() ([]*Output, error) {
+	value, found := (*r)["outputs"]
+	if !found || value == nil {
+		return nil, nil
+	}
+	// step: the outputs are decoded as generic maps, so we round trip them into the type
+	content, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []*Output
+	if err := yaml.Unmarshal(content, &outputs); err != nil {
+		return nil, fmt.Errorf("invalid outputs, error: %s", err)
+	}
+
+	return outputs, nil
+}
+
+// Expand returns a copy of the attributes with the references to variables in the string values
+// replaced, along with the names of any variables which are not defined
+func (r *Attributes) Expand(variables map[string]string) (*Attributes, []string) {
+	var missing []string
+	expanded := make(Attributes, len(*r))
+	for k, v := range *r {
+		if text, ok := v.(string); ok && !utils.ContainedIn(k, reservedAttributes) {
+			v = variableRegex.ReplaceAllStringFunc(text, func(ref string) string {
+				name := variableRegex.FindStringSubmatch(ref)[1]
+				value, found := variables[name]
+				if !found {
+					missing = append(missing, name)
+					return ref
+				}
+				return value
+			})
+		}
+		expanded[k] = v
+	}
+
+	return &expanded, missing
+}
+
+// GetKey returns the key of the output in the vault secret
+func (r *Output) GetKey() string {
+	if r.Key != "" {
+		return r.Key
+	}
+	items := strings.Split(r.Path, ".")
+
+	return items[len(items)-1]
+}
+
+// GetMode returns the file mode of the output
+func (r *Output) GetMode() os.FileMode {
+	if r.Mode == 0 {
+		return 0600
+	}
+
+	return r.Mode
+}
+
 // GetPath returns the uri of the config
 func (r *Attributes) GetPath(ns string) string {
 	return fmt.Sprintf("%s/%s", ns, r.URI())
@@ -82,17 +317,140 @@ func (r *Attributes) String() string {
 	return strings.Join(items, ",")
 }
 
+// Username returns the name of the user
 func (r User) Username() string {
-	if r.UserPass != nil {
+	switch {
+	case r.UserPass != nil:
 		return r.UserPass.Username
-	}
-	if r.UserToken != nil {
+	case r.UserToken != nil:
 		return r.UserToken.DisplayName
+	case r.LDAPUser != nil:
+		return r.LDAPUser.Username
+	case r.LDAPGroup != nil:
+		return r.LDAPGroup.Name
+	case r.GithubUser != nil:
+		return r.GithubUser.Username
+	case r.GithubTeam != nil:
+		return r.GithubTeam.Name
+	case r.AppID != nil:
+		return r.AppID.ID
+	case r.UserID != nil:
+		return r.UserID.ID
+	case r.Cert != nil:
+		return r.Cert.Name
 	}
 
 	return ""
 }
 
+// IsAbsent checks if the user should be removed
+func (r User) IsAbsent() bool {
+	return r.State == AbsentState
+}
+
+// IsAbsent checks if the auth backend should be removed
+func (r Auth) IsAbsent() bool {
+	return r.State == AbsentState
+}
+
+// IsAbsent checks if the backend should be removed
+func (r Backend) IsAbsent() bool {
+	return r.State == AbsentState
+}
+
+// IsAbsent checks if the policy should be removed
+func (r Policy) IsAbsent() bool {
+	return r.State == AbsentState
+}
+
+// IsAbsent checks if the secret should be removed
+func (r Secret) IsAbsent() bool {
+	return r.State == AbsentState
+}
+
+// GetType returns the type of the user
+func (r User) GetType() string {
+	switch {
+	case r.UserPass != nil:
+		return UserPassType
+	case r.UserToken != nil:
+		return UserTokenType
+	case r.LDAPUser != nil:
+		return LDAPUserType
+	case r.LDAPGroup != nil:
+		return LDAPGroupType
+	case r.GithubUser != nil:
+		return GithubUserType
+	case r.GithubTeam != nil:
+		return GithubTeamType
+	case r.AppID != nil:
+		return AppIDType
+	case r.UserID != nil:
+		return UserIDType
+	case r.Cert != nil:
+		return CertType
+	}
+
+	return ""
+}
+
+// GetPath returns the auth backend path of the user, defaulting to the path for the type
+func (r User) GetPath() string {
+	if r.Path != "" {
+		return r.Path
+	}
+	switch r.GetType() {
+	case UserPassType:
+		return "userpass"
+	case UserTokenType:
+		return "token"
+	case LDAPUserType, LDAPGroupType:
+		return "ldap"
+	case GithubUserType, GithubTeamType:
+		return "github"
+	case AppIDType, UserIDType:
+		return "app-id"
+	case CertType:
+		return "cert"
+	}
+
+	return ""
+}
+
+// GetCollection returns the collection in the auth backend the user lives under
+func (r User) GetCollection() string {
+	return userCollections[r.GetType()]
+}
+
+// GetURI returns the uri of the user in vault
+func (r User) GetURI() string {
+	return fmt.Sprintf("auth/%s/%s/%s", r.GetPath(), r.GetCollection(), r.Username())
+}
+
+// GetAuthCollections returns the user collections for a type of auth backend
+func GetAuthCollections(authType string) []string {
+	var list []string
+	for _, x := range authRegistry[authType] {
+		list = append(list, userCollections[x])
+	}
+
+	return list
+}
+
+// IsGenerated checks if the password for the user is generated
+func (r UserPass) IsGenerated() bool {
+	return r.Password == ""
+}
+
+// GetRenewThreshold returns the remaining ttl under which the token should be renewed
+func (r UserToken) GetRenewThreshold() time.Duration {
+	if r.RenewThreshold > 0 {
+		return r.RenewThreshold
+	}
+
+	return r.TTL / 2
+}
+
 // GetPolicies returns the policies associated to a user
 func (r User) GetPolicies() string {
 	if len(r.Policies) <= 0 {
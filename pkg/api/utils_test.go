@@ -0,0 +1,209 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserGetURI(t *testing.T) {
+	tests := []struct {
+		User *User
+		URI  string
+	}{
+		{
+			User: &User{UserPass: &UserPass{Username: "test"}},
+			URI:  "auth/userpass/users/test",
+		},
+		{
+			User: &User{Path: "extra/userpass", UserPass: &UserPass{Username: "test"}},
+			URI:  "auth/extra/userpass/users/test",
+		},
+		{
+			User: &User{LDAPGroup: &LDAPGroup{Name: "admins"}},
+			URI:  "auth/ldap/groups/admins",
+		},
+		{
+			User: &User{GithubTeam: &GithubTeam{Name: "platform"}},
+			URI:  "auth/github/map/teams/platform",
+		},
+		{
+			User: &User{UserID: &UserID{ID: "user"}},
+			URI:  "auth/app-id/map/user-id/user",
+		},
+		{
+			User: &User{Cert: &Cert{Name: "web"}},
+			URI:  "auth/cert/certs/web",
+		},
+	}
+
+	for i, c := range tests {
+		assert.Equal(t, c.URI, c.User.GetURI(), "case %d, the uri is not as expected", i)
+	}
+}
+
+func TestGetAuthCollections(t *testing.T) {
+	assert.Equal(t, []string{"users", "groups"}, GetAuthCollections("ldap"))
+	assert.Equal(t, []string{"map/app-id", "map/user-id"}, GetAuthCollections("app-id"))
+	assert.Empty(t, GetAuthCollections("token"))
+}
+
+func TestAttributesSettings(t *testing.T) {
+	attrs := &Attributes{"uri": "roles/test", "oneshot": true, "max_ttl": "1h"}
+	assert.Equal(t, map[string]interface{}{"max_ttl": "1h"}, attrs.Settings())
+}
+
+func TestBackendGetManagedItems(t *testing.T) {
+	backend := &Backend{
+		Attrs: []*Attributes{
+			{"uri": "config/root"},
+			{"uri": "roles/readonly"},
+			{"uri": "/roles/admin/"},
+			{"uri": "roles/admin/extra"},
+		},
+	}
+	assert.Equal(t, []string{"readonly", "admin"}, backend.GetManagedItems("roles"))
+	assert.Equal(t, []string{"readonly", "admin"}, backend.GetManagedItems("roles/"))
+	assert.Empty(t, backend.GetManagedItems("keys"))
+	backend.Keys = []*TransitKey{{Name: "default"}}
+	assert.Equal(t, []string{"default"}, backend.GetManagedItems("keys"))
+}
+
+func TestAttributesHash(t *testing.T) {
+	a := &Attributes{"uri": "root/generate/internal", "common_name": "example.com", "oneshot": true}
+	b := &Attributes{"common_name": "example.com", "uri": "root/generate/internal"}
+	c := &Attributes{"uri": "root/generate/internal", "common_name": "example.org", "oneshot": true}
+	assert.Equal(t, a.Hash("pki"), b.Hash("pki"))
+	assert.NotEqual(t, a.Hash("pki"), a.Hash("other/pki"))
+	assert.NotEqual(t, a.Hash("pki"), c.Hash("pki"))
+	(*a)["outputs"] = []interface{}{map[string]interface{}{"path": "data.certificate", "variable": "cert"}}
+	assert.Equal(t, a.Hash("pki"), b.Hash("pki"))
+}
+
+func TestAttributesGetOutputs(t *testing.T) {
+	attrs := &Attributes{
+		"uri": "root/generate/internal",
+		"outputs": []interface{}{
+			map[interface{}]interface{}{"path": "data.certificate", "file": "root.pem", "mode": 0644},
+			map[string]interface{}{"path": "data.issuing_ca", "vault-path": "secret/ca", "variable": "ca"},
+		},
+	}
+	outputs, err := attrs.GetOutputs()
+	assert.NoError(t, err)
+	if assert.Len(t, outputs, 2) {
+		assert.Equal(t, "root.pem", outputs[0].File)
+		assert.Equal(t, os.FileMode(0644), outputs[0].GetMode())
+		assert.Equal(t, "certificate", outputs[0].GetKey())
+		assert.Equal(t, os.FileMode(0600), outputs[1].GetMode())
+		assert.Equal(t, "issuing_ca", outputs[1].GetKey())
+		assert.Equal(t, "ca", outputs[1].Variable)
+	}
+	assert.NotContains(t, attrs.Settings(), "outputs")
+
+	outputs, err = (&Attributes{"uri": "config/root"}).GetOutputs()
+	assert.NoError(t, err)
+	assert.Empty(t, outputs)
+}
+
+func TestAttributesExpand(t *testing.T) {
+	attrs := &Attributes{"uri": "intermediate/set-signed", "certificate": "${cert}", "ttl": 10, "name": "${a}-${b}"}
+	expanded, missing := attrs.Expand(map[string]string{"cert": "PEM", "a": "x"})
+	assert.Equal(t, []string{"b"}, missing)
+	assert.Equal(t, "PEM", (*expanded)["certificate"])
+	assert.Equal(t, "x-${b}", (*expanded)["name"])
+	assert.Equal(t, 10, (*expanded)["ttl"])
+	assert.Equal(t, "${cert}", (*attrs)["certificate"])
+}
+
+func TestSecretDiff(t *testing.T) {
+	secret := &Secret{Path: "secret/app", Values: map[string]interface{}{"a": "1", "b": "2", "c": 3}}
+	diff := secret.Diff(map[string]interface{}{"b": "changed", "c": "3", "d": "4"})
+	assert.True(t, diff.HasChanged())
+	assert.Equal(t, []string{"a"}, diff.Added)
+	assert.Equal(t, []string{"b"}, diff.Changed)
+	assert.Equal(t, []string{"d"}, diff.Removed)
+
+	diff = secret.Diff(map[string]interface{}{"a": "1", "b": "2", "c": "3"})
+	assert.False(t, diff.HasChanged())
+
+	diff = secret.Diff(nil)
+	assert.Equal(t, []string{"a", "b", "c"}, diff.Added)
+}
+
+func TestHashValue(t *testing.T) {
+	assert.Equal(t, HashValue("value"), HashValue("value"))
+	assert.NotEqual(t, HashValue("value"), HashValue("other"))
+	assert.NotContains(t, HashValue("value"), "value")
+	assert.Len(t, HashValue("value"), len("sha256:")+12)
+}
+
+func TestSecretGetValues(t *testing.T) {
+	file, err := ioutil.TempFile("", "secret_file")
+	if err != nil {
+		t.FailNow()
+	}
+	defer os.Remove(file.Name())
+	file.Write([]byte{0x00, 0xff, 0x10})
+	file.Close()
+
+	secret := &Secret{
+		Path:   "secret/app",
+		Values: map[string]interface{}{"a": "1"},
+		Files: map[string]*SecretFile{
+			"raw":    {Path: file.Name()},
+			"binary": {Path: file.Name(), Base64: true},
+		},
+	}
+	values, err := secret.GetValues()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "1", "raw": "\x00\xff\x10", "binary": "AP8Q"}, values)
+	assert.Len(t, secret.Values, 1)
+
+	secret.Files["missing"] = &SecretFile{Path: "not_there"}
+	_, err = secret.GetValues()
+	assert.Error(t, err)
+}
+
+func TestSecretReconcile(t *testing.T) {
+	values := map[string]interface{}{"a": "1", "b": "2"}
+	current := map[string]interface{}{"a": "old", "c": "rotated", "d": "stale"}
+
+	secret := &Secret{Path: "secret/app"}
+	assert.Equal(t, values, secret.Reconcile(values, current))
+
+	secret.Mode = MergeMode
+	assert.Equal(t, map[string]interface{}{"a": "1", "b": "2", "c": "rotated", "d": "stale"}, secret.Reconcile(values, current))
+
+	secret.ManagedKeys = []string{"a", "b", "d"}
+	merged := secret.Reconcile(values, current)
+	assert.Equal(t, map[string]interface{}{"a": "1", "b": "2", "c": "rotated"}, merged)
+	assert.Equal(t, []string{"d"}, (&Secret{Values: merged}).Diff(current).Removed)
+
+	assert.Equal(t, values, secret.Reconcile(values, nil))
+}
+
+func TestIsAbsent(t *testing.T) {
+	assert.True(t, Secret{State: AbsentState}.IsAbsent())
+	assert.False(t, Secret{}.IsAbsent())
+	assert.False(t, Policy{State: PresentState}.IsAbsent())
+	assert.True(t, Backend{State: AbsentState}.IsAbsent())
+	assert.True(t, Auth{State: AbsentState}.IsAbsent())
+	assert.True(t, User{State: AbsentState}.IsAbsent())
+}
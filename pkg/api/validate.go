@@ -17,50 +17,84 @@ package api
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/UKHomeOffice/vaultctl/pkg/utils"
 )
 
-var (
-	supportedBackendTypes = []string{
-		"aws", "generic", "pki", "transit",
-		"cassandra", "consul", "cubbyhole", "mysql",
-		"postgres", "ssh", "custom",
-	}
-
-	supportAuthTypes = []string{
-		"userpass", "ldap", "token", "appid", "github", "mfa", "tls",
-	}
-)
-
 // IsValid validates the attributes
 func (r Attributes) IsValid() error {
 	if r.URI() == "" {
 		return fmt.Errorf("attributes must have a uri specified")
 	}
+	outputs, err := r.GetOutputs()
+	if err != nil {
+		return err
+	}
+	for _, x := range outputs {
+		if err := x.IsValid(); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
-// IsValid validates the auth backend
-func (r Auth) IsValid() error {
-	if r.Type == "" {
-		return fmt.Errorf("you must specify a auth type")
+// IsValid validates the output is ok
+func (r Output) IsValid() error {
+	if r.Path == "" {
+		return fmt.Errorf("the output must have a json path")
+	}
+	if r.File == "" && r.VaultPath == "" && r.Variable == "" {
+		return fmt.Errorf("the output: %s must have a file, vault-path or variable", r.Path)
+	}
+	if r.Variable != "" && !variableNameRegex.MatchString(r.Variable) {
+		return fmt.Errorf("the output: %s variable: %s is invalid, must match %s", r.Path, r.Variable, variableNameRegex.String())
+	}
+
+	return nil
+}
+
+// isValidState validates the state of a resource
+func isValidState(state string) error {
+	if state != "" && state != PresentState && state != AbsentState {
+		return fmt.Errorf("state: %s is invalid, must be %s or %s", state, PresentState, AbsentState)
 	}
+
+	return nil
+}
+
+// IsValid validates the auth backend, permitting only the auth types known to the registry
+func (r Auth) IsValid() error {
+	return r.IsValidWith(ValidationOptions{})
+}
+
+// IsValidWith validates the auth backend with the options
+func (r Auth) IsValidWith(options ValidationOptions) error {
 	if r.Path == "" {
 		return fmt.Errorf("you must specify a path")
 	}
 	if strings.HasSuffix(r.Path, "/") {
 		return fmt.Errorf("path should not end with /")
 	}
-	if !utils.ContainedIn(r.Type, supportAuthTypes) {
-		return fmt.Errorf("auth type: %s is a unsupported auth type", r.Type)
+	if err := isValidState(r.State); err != nil {
+		return err
+	}
+	// step: an absent auth backend only needs the path
+	if r.IsAbsent() {
+		return nil
+	}
+	if r.Type == "" {
+		return fmt.Errorf("you must specify a auth type")
+	}
+	if !IsKnownAuthType(r.Type) && !options.AllowUnknownTypes {
+		return fmt.Errorf("auth type: %s is a unsupported auth type, supported types are: %s", r.Type, supportedAuths())
 	}
 
 	for i, x := range r.Attrs {
 		if err := x.IsValid(); err != nil {
-			return fmt.Errorf("attribute %s invalid, error: %s", i, err)
+			return fmt.Errorf("attribute %d invalid, error: %s", i, err)
 		}
 	}
 
@@ -73,12 +107,52 @@ func (r *User) IsValid() error {
 		return fmt.Errorf("path should not end with /")
 	}
 
-	if r.UserPass != nil {
-		return r.UserPass.IsValid()
+	// step: ensure only one type of user is defined
+	count := 0
+	for _, x := range []bool{r.UserPass != nil, r.UserToken != nil, r.LDAPUser != nil, r.LDAPGroup != nil,
+		r.GithubUser != nil, r.GithubTeam != nil, r.AppID != nil, r.UserID != nil, r.Cert != nil} {
+		if x {
+			count++
+		}
+	}
+	if count > 1 {
+		return fmt.Errorf("a user can only have one type of authentication")
+	}
+	if err := isValidState(r.State); err != nil {
+		return err
+	}
+	// step: an absent user only needs to be identifiable
+	if r.IsAbsent() {
+		switch {
+		case r.GetType() == "":
+			return fmt.Errorf("you have not added authentication to the user")
+		case r.UserToken != nil && r.UserToken.ID == "" && r.UserToken.TokenPath == "":
+			return fmt.Errorf("an absent token must have an id or token-path")
+		case r.UserToken == nil && r.Username() == "":
+			return fmt.Errorf("an absent user must have a name")
+		}
+		return nil
 	}
 
-	if r.UserToken != nil {
+	switch r.GetType() {
+	case UserPassType:
+		return r.UserPass.IsValid()
+	case UserTokenType:
 		return r.UserToken.IsValid()
+	case LDAPUserType:
+		return r.LDAPUser.IsValid()
+	case LDAPGroupType:
+		return r.LDAPGroup.IsValid()
+	case GithubUserType:
+		return r.GithubUser.IsValid()
+	case GithubTeamType:
+		return r.GithubTeam.IsValid()
+	case AppIDType:
+		return r.AppID.IsValid()
+	case UserIDType:
+		return r.UserID.IsValid()
+	case CertType:
+		return r.Cert.IsValid()
 	}
 
 	return fmt.Errorf("you have not added authentication to the user")
@@ -89,8 +163,15 @@ func (r UserPass) IsValid() error {
 	if r.Username == "" {
 		return fmt.Errorf("does not have a username")
 	}
-	if r.Password == "" {
-		return fmt.Errorf("does not have a password")
+	if r.Password == "" && r.PasswordPath == "" && r.PasswordFile == "" {
+		return fmt.Errorf("does not have a password or somewhere to store a generated one")
+	}
+	if r.PasswordFile != "" {
+		switch filepath.Ext(r.PasswordFile) {
+		case ".yml", ".yaml", ".json":
+		default:
+			return fmt.Errorf("the password file: %s must be a yml or json file", r.PasswordFile)
+		}
 	}
 
 	return nil
@@ -101,6 +182,87 @@ func (r UserToken) IsValid() error {
 	if r.DisplayName == "" {
 		return fmt.Errorf("you must specify a display name for the token")
 	}
+	if r.ID == "" && r.TokenPath == "" {
+		return fmt.Errorf("the token: %s must have an id or a token-path to track it", r.DisplayName)
+	}
+	if r.RenewThreshold < 0 {
+		return fmt.Errorf("the token: %s, renew threshold must be positive", r.DisplayName)
+	}
+
+	return nil
+}
+
+// IsValid checks the ldap user is valid
+func (r LDAPUser) IsValid() error {
+	if r.Username == "" {
+		return fmt.Errorf("the ldap user does not have a username")
+	}
+
+	return nil
+}
+
+// IsValid checks the ldap group is valid
+func (r LDAPGroup) IsValid() error {
+	if r.Name == "" {
+		return fmt.Errorf("the ldap group does not have a name")
+	}
+
+	return nil
+}
+
+// IsValid checks the github user is valid
+func (r GithubUser) IsValid() error {
+	if r.Username == "" {
+		return fmt.Errorf("the github user does not have a username")
+	}
+
+	return nil
+}
+
+// IsValid checks the github team is valid
+func (r GithubTeam) IsValid() error {
+	if r.Name == "" {
+		return fmt.Errorf("the github team does not have a name")
+	}
+
+	return nil
+}
+
+// IsValid checks the app-id is valid
+func (r AppID) IsValid() error {
+	if r.ID == "" {
+		return fmt.Errorf("the app-id does not have an id")
+	}
+
+	return nil
+}
+
+// IsValid checks the user-id is valid
+func (r UserID) IsValid() error {
+	if r.ID == "" {
+		return fmt.Errorf("the user-id does not have an id")
+	}
+	if len(r.AppIDs) <= 0 {
+		return fmt.Errorf("the user-id: %s must be mapped to at least one app-id", r.ID)
+	}
+
+	return nil
+}
+
+// IsValid checks the certificate is valid
+func (r Cert) IsValid() error {
+	if r.Name == "" {
+		return fmt.Errorf("the certificate does not have a name")
+	}
+	if r.Certificate == "" {
+		return fmt.Errorf("the certificate: %s does not have a certificate file", r.Name)
+	}
+	if !utils.IsFile(r.Certificate) {
+		return fmt.Errorf("the certificate: %s, file: %s does not exist", r.Name, r.Certificate)
+	}
+	if r.TTL < 0 {
+		return fmt.Errorf("the certificate: %s, ttl must be positive", r.Name)
+	}
 
 	return nil
 }
@@ -110,9 +272,60 @@ func (r Secret) IsValid() error {
 	if r.Path == "" {
 		return fmt.Errorf("the secret must have a path")
 	}
-	if r.Values == nil || len(r.Values) <= 0 {
+	if err := isValidState(r.State); err != nil {
+		return err
+	}
+	// step: an absent secret only needs the path
+	if r.IsAbsent() {
+		return nil
+	}
+	if len(r.Values) <= 0 && len(r.Files) <= 0 {
 		return fmt.Errorf("the secret must have some values")
 	}
+	if r.Mode != "" && r.Mode != ReplaceMode && r.Mode != MergeMode {
+		return fmt.Errorf("secret: %s, mode: %s is invalid, must be %s or %s", r.Path, r.Mode, ReplaceMode, MergeMode)
+	}
+	if len(r.ManagedKeys) > 0 && r.Mode != MergeMode {
+		return fmt.Errorf("secret: %s, managed_keys can only be used in %s mode", r.Path, MergeMode)
+	}
+	for k, v := range r.Values {
+		generator, found, err := GetGenerator(v)
+		if err != nil {
+			return fmt.Errorf("secret: %s, key: %s, %s", r.Path, k, err)
+		}
+		if !found {
+			continue
+		}
+		if err := generator.IsValid(); err != nil {
+			return fmt.Errorf("secret: %s, key: %s, %s", r.Path, k, err)
+		}
+	}
+	for k, x := range r.Files {
+		if _, found := r.Values[k]; found {
+			return fmt.Errorf("secret: %s, key: %s is defined in both the values and files", r.Path, k)
+		}
+		if x == nil || x.Path == "" {
+			return fmt.Errorf("secret: %s, key: %s must have a file path", r.Path, k)
+		}
+		if !utils.IsFile(x.Path) {
+			return fmt.Errorf("secret: %s, key: %s, the file: %s does not exist", r.Path, k, x.Path)
+		}
+	}
+
+	return nil
+}
+
+// IsValid validates the target is ok
+func (r Target) IsValid() error {
+	if r.Name == "" {
+		return fmt.Errorf("the target must have a name")
+	}
+	if r.Address == "" {
+		return fmt.Errorf("the target: %s must have an address", r.Name)
+	}
+	if r.Username != "" && r.Password == "" {
+		return fmt.Errorf("the target: %s has a username but no password", r.Name)
+	}
 
 	return nil
 }
@@ -123,14 +336,26 @@ func (r Policy) IsValid() error {
 		return fmt.Errorf("the policy must have a name")
 	}
 
-	return nil
+	return isValidState(r.State)
 }
 
-// IsValid validates the backend is ok
+// IsValid validates the backend is ok, permitting only the backend types known to the registry
 func (r Backend) IsValid() error {
+	return r.IsValidWith(ValidationOptions{})
+}
+
+// IsValidWith validates the backend with the options
+func (r Backend) IsValidWith(options ValidationOptions) error {
 	if r.Path == "" {
 		return fmt.Errorf("backend must have a path")
 	}
+	if err := isValidState(r.State); err != nil {
+		return fmt.Errorf("backend: %s, %s", r.Path, err)
+	}
+	// step: an absent backend only needs the path
+	if r.IsAbsent() {
+		return nil
+	}
 	if r.Type == "" {
 		return fmt.Errorf("backend %s must have a type", r.Path)
 	}
@@ -146,22 +371,123 @@ func (r Backend) IsValid() error {
 	if r.MaxLeaseTTL.Seconds() < 0 {
 		return fmt.Errorf("backend: %s, max lease time must be positive", r.Path)
 	}
-	if !utils.ContainedIn(r.Type, supportedBackendTypes) {
+	if r.MovedFrom != "" && r.GetMovedFrom() == r.GetPath() {
+		return fmt.Errorf("backend: %s, moved_from cannot be the same as the path", r.Path)
+	}
+	if !IsKnownBackendType(r.Type) && !options.AllowUnknownTypes {
 		return fmt.Errorf("backend: %s, unsupported type: %s, supported types are: %s", r.Path, r.Type, supportedBackends())
 	}
+	if r.PKI != nil {
+		if r.Type != "pki" {
+			return fmt.Errorf("backend: %s, a pki definition is only permitted on a pki backend", r.Path)
+		}
+		if err := r.PKI.IsValid(r.GetPath()); err != nil {
+			return fmt.Errorf("backend: %s, %s", r.Path, err)
+		}
+	}
+	if len(r.Keys) > 0 && r.Type != "transit" {
+		return fmt.Errorf("backend: %s, keys are only permitted on a transit backend", r.Path)
+	}
+	names := make(map[string]bool, 0)
+	for _, x := range r.Keys {
+		if err := x.IsValid(); err != nil {
+			return fmt.Errorf("backend: %s, %s", r.Path, err)
+		}
+		if names[x.Name] {
+			return fmt.Errorf("backend: %s, the key: %s is defined more than once", r.Path, x.Name)
+		}
+		names[x.Name] = true
+	}
+	for _, x := range r.Managed {
+		collection := strings.Trim(x, "/")
+		if collection == "" {
+			return fmt.Errorf("backend: %s, managed collection cannot be empty", r.Path)
+		}
+		if len(backendRegistry[r.Type]) > 0 && getURISchema(r.Type, collection+"/item") == nil {
+			return fmt.Errorf("backend: %s, managed collection: %s is not a known collection of type: %s", r.Path, x, r.Type)
+		}
+	}
 	if r.Attrs != nil && len(r.Attrs) > 0 {
 		for _, x := range r.Attrs {
 			// step: ensure the config has a uri
 			if x.URI() == "" {
 				return fmt.Errorf("backend: %s, config for must have uri", r.Path)
 			}
+			if err := x.IsValid(); err != nil {
+				return fmt.Errorf("backend: %s, uri: %s, %s", r.Path, x.URI(), err)
+			}
+			// step: validate the attribute against the schema of a known uri
+			if x.IsRaw() {
+				continue
+			}
+			if schema := getURISchema(r.Type, x.URI()); schema != nil {
+				if err := schema.validate(x); err != nil {
+					return fmt.Errorf("backend: %s, uri: %s, %s", r.Path, x.URI(), err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsValid validates the pki definition is ok
+func (r PKI) IsValid(path string) error {
+	if (r.Root == nil) == (r.Intermediate == nil) {
+		return fmt.Errorf("the pki must be either a root or an intermediate")
+	}
+	if r.Root != nil {
+		if err := r.Root.IsValid(); err != nil {
+			return err
+		}
+		if r.Root.SignedBy != "" {
+			return fmt.Errorf("a root certificate authority cannot be signed-by another")
+		}
+	}
+	if r.Intermediate != nil {
+		if err := r.Intermediate.IsValid(); err != nil {
+			return err
+		}
+		if r.Intermediate.SignedBy == "" {
+			return fmt.Errorf("an intermediate certificate authority must be signed-by another")
+		}
+		if strings.Trim(r.Intermediate.SignedBy, "/") == path {
+			return fmt.Errorf("an intermediate certificate authority cannot sign itself")
 		}
 	}
 
 	return nil
 }
 
-// supportedBackends returns a list of supported backend types
-func supportedBackends() string {
-	return strings.Join(supportedBackendTypes, ",")
+// IsValid validates the certificate authority is ok
+func (r CertificateAuthority) IsValid() error {
+	if r.CommonName == "" {
+		return fmt.Errorf("the certificate authority must have a common-name")
+	}
+	if r.TTL < 0 {
+		return fmt.Errorf("the certificate authority ttl must be positive")
+	}
+	if r.KeyType != "" && !utils.ContainedIn(r.KeyType, []string{"rsa", "ec"}) {
+		return fmt.Errorf("the certificate authority key-type must be rsa or ec")
+	}
+
+	return nil
+}
+
+// IsValid validates the transit key is ok
+func (r TransitKey) IsValid() error {
+	if r.Name == "" {
+		return fmt.Errorf("the transit key must have a name")
+	}
+	if strings.Contains(r.Name, "/") {
+		return fmt.Errorf("the transit key: %s name cannot contain a /", r.Name)
+	}
+	if r.MinDecryptionVersion < 0 {
+		return fmt.Errorf("the transit key: %s min-decryption-version must be positive", r.Name)
+	}
+	if r.RotateEvery < 0 {
+		return fmt.Errorf("the transit key: %s rotate-every must be positive", r.Name)
+	}
+
+	return nil
 }
@@ -16,7 +16,10 @@ limitations under the License.
 package api
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -41,6 +44,26 @@ func TestUserIsValid(t *testing.T) {
 			}},
 			Ok: true,
 		},
+		{
+			User: &User{UserPass: &UserPass{
+				Username:     "test",
+				PasswordPath: "secret/users/test",
+			}},
+			Ok: true,
+		},
+		{
+			User: &User{UserPass: &UserPass{
+				Username:     "test",
+				PasswordFile: "test.txt",
+			}},
+		},
+		{
+			User: &User{UserPass: &UserPass{
+				Username:     "test",
+				PasswordFile: "test.yml",
+			}},
+			Ok: true,
+		},
 		{
 			User: &User{
 				UserPass: &UserPass{Username: "test", Password: "pass"},
@@ -48,6 +71,55 @@ func TestUserIsValid(t *testing.T) {
 			},
 			Ok: true,
 		},
+		{
+			User: &User{
+				UserPass:  &UserPass{Username: "test", Password: "pass"},
+				LDAPGroup: &LDAPGroup{Name: "admins"},
+			},
+		},
+		{
+			User: &User{UserToken: &UserToken{DisplayName: "jenkins"}},
+		},
+		{
+			User: &User{UserToken: &UserToken{DisplayName: "jenkins", ID: "token"}},
+			Ok:   true,
+		},
+		{
+			User: &User{UserToken: &UserToken{DisplayName: "jenkins", TokenPath: "secret/tokens/jenkins"}},
+			Ok:   true,
+		},
+		{
+			User: &User{LDAPUser: &LDAPUser{}},
+		},
+		{
+			User: &User{LDAPUser: &LDAPUser{Username: "test", Groups: []string{"admins"}}},
+			Ok:   true,
+		},
+		{
+			User: &User{LDAPGroup: &LDAPGroup{Name: "admins"}, Policies: []string{"pol"}},
+			Ok:   true,
+		},
+		{
+			User: &User{GithubTeam: &GithubTeam{Name: "platform"}, Policies: []string{"pol"}},
+			Ok:   true,
+		},
+		{
+			User: &User{GithubUser: &GithubUser{}},
+		},
+		{
+			User: &User{AppID: &AppID{ID: "app"}},
+			Ok:   true,
+		},
+		{
+			User: &User{UserID: &UserID{ID: "user"}},
+		},
+		{
+			User: &User{UserID: &UserID{ID: "user", AppIDs: []string{"app"}}},
+			Ok:   true,
+		},
+		{
+			User: &User{Cert: &Cert{Name: "web", Certificate: "not_there.pem"}},
+		},
 	}
 
 	for i, u := range users {
@@ -61,6 +133,12 @@ func TestUserIsValid(t *testing.T) {
 }
 
 func TestSecretIsValid(t *testing.T) {
+	file, err := ioutil.TempFile("", "secret_file")
+	if err != nil {
+		t.FailNow()
+	}
+	defer os.Remove(file.Name())
+
 	tests := []struct {
 		Secret *Secret
 		Ok     bool
@@ -86,6 +164,71 @@ func TestSecretIsValid(t *testing.T) {
 			},
 			Ok: true,
 		},
+		{
+			Secret: &Secret{
+				Path:  "/",
+				Files: map[string]*SecretFile{"tls.crt": {Path: file.Name()}},
+			},
+			Ok: true,
+		},
+		{
+			Secret: &Secret{
+				Path:  "/",
+				Files: map[string]*SecretFile{"keystore.jks": {Path: file.Name(), Base64: true}},
+			},
+			Ok: true,
+		},
+		{
+			Secret: &Secret{
+				Path:   "/",
+				Values: map[string]interface{}{"password": map[string]interface{}{"generate": "uuid"}},
+			},
+			Ok: true,
+		},
+		{
+			Secret: &Secret{
+				Path:        "/",
+				Values:      map[string]interface{}{"a": "1"},
+				Mode:        "merge",
+				ManagedKeys: []string{"a", "b"},
+			},
+			Ok: true,
+		},
+		{
+			Secret: &Secret{Path: "/", Values: map[string]interface{}{"a": "1"}, Mode: "replace"},
+			Ok:     true,
+		},
+		{
+			Secret: &Secret{Path: "/", Values: map[string]interface{}{"a": "1"}, Mode: "append"},
+		},
+		{
+			Secret: &Secret{Path: "/", Values: map[string]interface{}{"a": "1"}, ManagedKeys: []string{"a"}},
+		},
+		{
+			Secret: &Secret{
+				Path:   "/",
+				Values: map[string]interface{}{"password": map[string]interface{}{"generate": "base32"}},
+			},
+		},
+		{
+			Secret: &Secret{
+				Path:  "/",
+				Files: map[string]*SecretFile{"tls.crt": {Path: "not_there.crt"}},
+			},
+		},
+		{
+			Secret: &Secret{
+				Path:  "/",
+				Files: map[string]*SecretFile{"tls.crt": {}},
+			},
+		},
+		{
+			Secret: &Secret{
+				Path:   "/",
+				Values: map[string]interface{}{"tls.crt": "cert"},
+				Files:  map[string]*SecretFile{"tls.crt": {Path: file.Name()}},
+			},
+		},
 	}
 
 	for i, c := range tests {
@@ -119,6 +262,24 @@ func TestBackendIsValid(t *testing.T) {
 			Backend: &Backend{Path: "/", Description: "test", Type: "mysql"},
 			Ok:      true,
 		},
+		{
+			Backend: &Backend{Path: "db", Description: "test", Type: "mysql", MovedFrom: "/db/"},
+		},
+		{
+			Backend: &Backend{Path: "db", Description: "test", Type: "mysql", Managed: []string{"roles"}},
+			Ok:      true,
+		},
+		{
+			Backend: &Backend{Path: "db", Description: "test", Type: "mysql", Managed: []string{"users"}},
+		},
+		{
+			Backend: &Backend{Path: "db", Description: "test", Type: "custom", Managed: []string{"users"}},
+			Ok:      true,
+		},
+		{
+			Backend: &Backend{Path: "platform/db", Description: "test", Type: "mysql", MovedFrom: "db"},
+			Ok:      true,
+		},
 		{
 			Backend: &Backend{
 				Path: "/", Description: "test", Type: "mysql",
@@ -151,3 +312,233 @@ func TestBackendIsValid(t *testing.T) {
 func TestSupportedBackends(t *testing.T) {
 	assert.NotEmpty(t, supportedBackends())
 }
+
+func TestTargetIsValid(t *testing.T) {
+	tests := []struct {
+		Target *Target
+		Ok     bool
+	}{
+		{
+			Target: &Target{},
+		},
+		{
+			Target: &Target{Name: "primary"},
+		},
+		{
+			Target: &Target{Name: "primary", Address: "https://127.0.0.1:8200", Username: "admin"},
+		},
+		{
+			Target: &Target{Name: "primary", Address: "https://127.0.0.1:8200"},
+			Ok:     true,
+		},
+		{
+			Target: &Target{Name: "dr", Address: "https://127.0.0.1:8200", Username: "admin", Password: "pass"},
+			Ok:     true,
+		},
+	}
+
+	for i, c := range tests {
+		err := c.Target.IsValid()
+		if !c.Ok {
+			assert.Error(t, err, "case %d should have errored", i)
+		} else {
+			assert.NoError(t, err, "case %d should have not errored", i)
+		}
+	}
+}
+
+func TestSupportedAuths(t *testing.T) {
+	assert.NotEmpty(t, supportedAuths())
+}
+
+func TestAllowUnknownTypes(t *testing.T) {
+	backend := &Backend{Path: "test", Description: "test", Type: "nomad"}
+	auth := &Auth{Path: "k8s", Type: "kubernetes"}
+	assert.Error(t, backend.IsValid())
+	assert.Error(t, auth.IsValid())
+	assert.NoError(t, (&Backend{Path: "test", Description: "test", Type: "rabbitmq"}).IsValid())
+	assert.NoError(t, (&Auth{Path: "approle", Type: "approle"}).IsValid())
+
+	options := ValidationOptions{AllowUnknownTypes: true}
+	assert.NoError(t, backend.IsValidWith(options))
+	assert.NoError(t, auth.IsValidWith(options))
+}
+
+func TestPKIIsValid(t *testing.T) {
+	tests := []struct {
+		Backend *Backend
+		Ok      bool
+	}{
+		{
+			Backend: &Backend{Path: "pki", Description: "test", Type: "pki", PKI: &PKI{}},
+		},
+		{
+			Backend: &Backend{Path: "pki", Description: "test", Type: "generic",
+				PKI: &PKI{Root: &CertificateAuthority{CommonName: "example.com"}}},
+		},
+		{
+			Backend: &Backend{Path: "pki", Description: "test", Type: "pki",
+				PKI: &PKI{Root: &CertificateAuthority{CommonName: "example.com"}}},
+			Ok: true,
+		},
+		{
+			Backend: &Backend{Path: "pki", Description: "test", Type: "pki",
+				PKI: &PKI{Root: &CertificateAuthority{}}},
+		},
+		{
+			Backend: &Backend{Path: "pki-int", Description: "test", Type: "pki",
+				PKI: &PKI{Intermediate: &CertificateAuthority{CommonName: "int.example.com"}}},
+		},
+		{
+			Backend: &Backend{Path: "pki-int", Description: "test", Type: "pki",
+				PKI: &PKI{Intermediate: &CertificateAuthority{CommonName: "int.example.com", SignedBy: "pki-int"}}},
+		},
+		{
+			Backend: &Backend{Path: "pki-int", Description: "test", Type: "pki",
+				PKI: &PKI{Intermediate: &CertificateAuthority{CommonName: "int.example.com", SignedBy: "pki", KeyType: "dsa"}}},
+		},
+		{
+			Backend: &Backend{Path: "pki-int", Description: "test", Type: "pki",
+				PKI: &PKI{Intermediate: &CertificateAuthority{CommonName: "int.example.com", SignedBy: "pki"}}},
+			Ok: true,
+		},
+	}
+
+	for i, c := range tests {
+		err := c.Backend.IsValid()
+		if !c.Ok {
+			assert.Error(t, err, "case %d should have errored", i)
+		} else {
+			assert.NoError(t, err, "case %d should have not errored", i)
+		}
+	}
+}
+
+func TestTransitKeysIsValid(t *testing.T) {
+	tests := []struct {
+		Backend *Backend
+		Ok      bool
+	}{
+		{
+			Backend: &Backend{Path: "transit", Description: "test", Type: "transit",
+				Keys: []*TransitKey{{Name: "default"}}},
+			Ok: true,
+		},
+		{
+			Backend: &Backend{Path: "transit", Description: "test", Type: "generic",
+				Keys: []*TransitKey{{Name: "default"}}},
+		},
+		{
+			Backend: &Backend{Path: "transit", Description: "test", Type: "transit",
+				Keys: []*TransitKey{{}}},
+		},
+		{
+			Backend: &Backend{Path: "transit", Description: "test", Type: "transit",
+				Keys: []*TransitKey{{Name: "app/default"}}},
+		},
+		{
+			Backend: &Backend{Path: "transit", Description: "test", Type: "transit",
+				Keys: []*TransitKey{{Name: "default"}, {Name: "default"}}},
+		},
+		{
+			Backend: &Backend{Path: "transit", Description: "test", Type: "transit",
+				Keys: []*TransitKey{{Name: "default", MinDecryptionVersion: -1}}},
+		},
+		{
+			Backend: &Backend{Path: "transit", Description: "test", Type: "transit",
+				Keys: []*TransitKey{{Name: "default", RotateEvery: -time.Hour}}},
+		},
+		{
+			Backend: &Backend{Path: "transit", Description: "test", Type: "transit",
+				Keys: []*TransitKey{{Name: "default", Derived: true, DeletionAllowed: true,
+					MinDecryptionVersion: 2, RotateEvery: 720 * time.Hour}}},
+			Ok: true,
+		},
+	}
+
+	for i, c := range tests {
+		err := c.Backend.IsValid()
+		if !c.Ok {
+			assert.Error(t, err, "case %d should have errored", i)
+		} else {
+			assert.NoError(t, err, "case %d should have not errored", i)
+		}
+	}
+}
+
+func TestAttributesOutputsIsValid(t *testing.T) {
+	tests := []struct {
+		Outputs interface{}
+		Ok      bool
+	}{
+		{
+			Outputs: []interface{}{map[string]interface{}{"path": "data.certificate", "file": "root.pem"}},
+			Ok:      true,
+		},
+		{
+			Outputs: []interface{}{map[string]interface{}{"path": "data.csr", "variable": "csr_1"}},
+			Ok:      true,
+		},
+		{
+			Outputs: []interface{}{map[string]interface{}{"path": "data.certificate", "vault-path": "secret/ca", "key": "cert"}},
+			Ok:      true,
+		},
+		{
+			Outputs: []interface{}{map[string]interface{}{"file": "root.pem"}},
+		},
+		{
+			Outputs: []interface{}{map[string]interface{}{"path": "data.certificate"}},
+		},
+		{
+			Outputs: []interface{}{map[string]interface{}{"path": "data.csr", "variable": "1-csr"}},
+		},
+		{
+			Outputs: "data.certificate",
+		},
+	}
+
+	for i, c := range tests {
+		err := Attributes{"uri": "root/generate/internal", "outputs": c.Outputs}.IsValid()
+		if !c.Ok {
+			assert.Error(t, err, "case %d should have errored", i)
+		} else {
+			assert.NoError(t, err, "case %d should have not errored", i)
+		}
+	}
+}
+
+func TestAbsentStateIsValid(t *testing.T) {
+	tests := []struct {
+		Resource interface {
+			IsValid() error
+		}
+		Ok bool
+	}{
+		{Resource: &Secret{Path: "secret/old", State: "absent"}, Ok: true},
+		{Resource: &Secret{Path: "secret/old", State: "deleted"}},
+		{Resource: &Secret{State: "absent"}},
+		{Resource: &Policy{Name: "old", State: "absent"}, Ok: true},
+		{Resource: &Policy{Name: "old", State: "gone"}},
+		{Resource: &Backend{Path: "old", State: "absent"}, Ok: true},
+		{Resource: &Backend{Path: "old", State: "present"}},
+		{Resource: &Backend{State: "absent"}},
+		{Resource: &Auth{Path: "old", State: "absent"}, Ok: true},
+		{Resource: &Auth{Path: "old", Type: "userpass", State: "removed"}},
+		{Resource: &User{UserPass: &UserPass{Username: "old"}, State: "absent"}, Ok: true},
+		{Resource: &User{LDAPGroup: &LDAPGroup{Name: "old"}, State: "absent"}, Ok: true},
+		{Resource: &User{UserToken: &UserToken{TokenPath: "secret/tokens/old"}, State: "absent"}, Ok: true},
+		{Resource: &User{UserToken: &UserToken{}, State: "absent"}},
+		{Resource: &User{UserPass: &UserPass{}, State: "absent"}},
+		{Resource: &User{State: "absent"}},
+		{Resource: &User{UserPass: &UserPass{Username: "old", Password: "password"}, State: "unknown"}},
+	}
+
+	for i, c := range tests {
+		err := c.Resource.IsValid()
+		if !c.Ok {
+			assert.Error(t, err, "case %d should have errored", i)
+		} else {
+			assert.NoError(t, err, "case %d should have not errored", i)
+		}
+	}
+}
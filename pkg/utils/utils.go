@@ -17,16 +17,28 @@ package utils
 
 import (
 	"bytes"
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"gopkg.in/yaml.v2"
 	"io"
 	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
+const (
+	// AlphaNumeric is the charset of letters and digits
+	AlphaNumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	// PasswordCharset is the charset used when generating passwords
+	PasswordCharset = AlphaNumeric + "!#%+-.:=@^_~"
+)
+
 // DecodeFile decodes the file
 func DecodeFile(path string, data interface{}) error {
 	// step: read in the file contents
@@ -154,3 +166,221 @@ func IsFile(path string) bool {
 
 	return !stat.IsDir()
 }
+
+// RandomString generates a cryptographically random string of the given length from the charset
+func RandomString(length int, charset string) (string, error) {
+	if length <= 0 {
+		return "", fmt.Errorf("the length must be positive")
+	}
+	if charset == "" {
+		return "", fmt.Errorf("the charset cannot be empty")
+	}
+	max := big.NewInt(int64(len(charset)))
+	b := make([]byte, length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = charset[n.Int64()]
+	}
+
+	return string(b), nil
+}
+
+// JSONPath extracts a field from a decoded json document using a dotted path, where list
+// elements are referenced by index i.e. data.certificate or data.ca_chain[0]
+func JSONPath(doc interface{}, path string) (interface{}, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return doc, nil
+	}
+	current := doc
+	for _, element := range strings.Split(strings.Replace(path, "[", ".[", -1), ".") {
+		if element == "" {
+			continue
+		}
+		// step: check for a list index
+		if strings.HasPrefix(element, "[") && strings.HasSuffix(element, "]") {
+			index, err := strconv.Atoi(strings.Trim(element, "[]"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid index: %s in path: %s", element, path)
+			}
+			list, ok := current.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("the element before: %s in path: %s is not a list", element, path)
+			}
+			if index < 0 || index >= len(list) {
+				return nil, fmt.Errorf("the index: %s in path: %s is out of range", element, path)
+			}
+			current = list[index]
+			continue
+		}
+		fields, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the element before: %s in path: %s is not an object", element, path)
+		}
+		value, found := fields[element]
+		if !found {
+			return nil, fmt.Errorf("the field: %s in path: %s was not found", element, path)
+		}
+		current = value
+	}
+
+	return current, nil
+}
+
+// ParseDotenv parses the content of a dotenv file into a map of values
+func ParseDotenv(content string) (map[string]string, error) {
+	values := make(map[string]string, 0)
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		items := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(items[0])
+		if len(items) != 2 || key == "" {
+			return nil, fmt.Errorf("invalid line: %d, expected KEY=VALUE", i+1)
+		}
+		value := strings.TrimSpace(items[1])
+		switch {
+		case len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`):
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value on line: %d, error: %s", i+1, err)
+			}
+			value = unquoted
+		case len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"):
+			value = value[1 : len(value)-1]
+		default:
+			// step: remove any trailing comment
+			if n := strings.Index(value, " #"); n >= 0 {
+				value = strings.TrimSpace(value[:n])
+			}
+		}
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+// ParseProperties parses the content of a java properties file into a map of values
+func ParseProperties(content string) (map[string]string, error) {
+	values := make(map[string]string, 0)
+	lines := strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		// step: join any continuation lines
+		for isContinued(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		// step: find the first unescaped separator
+		index := -1
+		for j := 0; j < len(line); j++ {
+			if line[j] == '\\' {
+				j++
+				continue
+			}
+			if line[j] == '=' || line[j] == ':' || line[j] == ' ' || line[j] == '\t' {
+				index = j
+				break
+			}
+		}
+		key, value := line, ""
+		if index >= 0 {
+			key = line[:index]
+			value = strings.TrimLeft(line[index:], " \t\f")
+			if strings.HasPrefix(value, "=") || strings.HasPrefix(value, ":") {
+				value = strings.TrimLeft(value[1:], " \t\f")
+			}
+		}
+		values[unescapeProperty(key)] = unescapeProperty(value)
+	}
+
+	return values, nil
+}
+
+// isContinued checks if a properties line ends with an odd number of backslashes
+func isContinued(line string) bool {
+	count := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+
+	return count%2 == 1
+}
+
+// unescapeProperty removes the escaping from a properties key or value
+func unescapeProperty(value string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 >= len(value) {
+			buf.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 't':
+			buf.WriteByte('\t')
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 'f':
+			buf.WriteByte('\f')
+		default:
+			buf.WriteByte(value[i])
+		}
+	}
+
+	return buf.String()
+}
+
+// EnvName mangles a key into an environment variable name with an optional prefix, i.e.
+// db.password becomes DB_PASSWORD
+func EnvName(prefix, key string) string {
+	mangled := []byte(strings.ToUpper(prefix + key))
+	for i, c := range mangled {
+		if !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '_' {
+			mangled[i] = '_'
+		}
+	}
+	if len(mangled) > 0 && mangled[0] >= '0' && mangled[0] <= '9' {
+		return "_" + string(mangled)
+	}
+
+	return string(mangled)
+}
+
+// IsSameAddress checks if two url addresses refer to the same service, ignoring the case of
+// the host, a trailing slash and an explicit default port
+func IsSameAddress(a, b string) bool {
+	return normalizeAddress(a) == normalizeAddress(b)
+}
+
+// normalizeAddress returns a canonical form of the url address
+func normalizeAddress(address string) string {
+	address = strings.TrimSpace(address)
+	location, err := url.Parse(address)
+	if err != nil || location.Host == "" {
+		return strings.TrimSuffix(strings.ToLower(address), "/")
+	}
+	scheme := strings.ToLower(location.Scheme)
+	host := strings.ToLower(location.Host)
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		switch scheme {
+		case "http":
+			host += ":80"
+		case "https":
+			host += ":443"
+		}
+	}
+
+	return scheme + "://" + host + strings.TrimSuffix(location.Path, "/")
+}
@@ -18,6 +18,7 @@ package utils
 import (
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -51,3 +52,140 @@ func TestIsFile(t *testing.T) {
 	defer os.Remove(file.Name())
 	assert.True(t, IsFile(file.Name()))
 }
+
+func TestRandomString(t *testing.T) {
+	_, err := RandomString(0, AlphaNumeric)
+	assert.Error(t, err)
+	_, err = RandomString(10, "")
+	assert.Error(t, err)
+	value, err := RandomString(32, AlphaNumeric)
+	assert.NoError(t, err)
+	assert.Len(t, value, 32)
+	for _, x := range value {
+		assert.True(t, strings.ContainsRune(AlphaNumeric, x))
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	doc := map[string]interface{}{
+		"data": map[string]interface{}{
+			"certificate": "cert",
+			"ca_chain":    []interface{}{"ca1", "ca2"},
+		},
+	}
+	tests := []struct {
+		Path  string
+		Value interface{}
+		Ok    bool
+	}{
+		{Path: "data.certificate", Value: "cert", Ok: true},
+		{Path: "$.data.certificate", Value: "cert", Ok: true},
+		{Path: "data.ca_chain[1]", Value: "ca2", Ok: true},
+		{Path: "data.ca_chain.[0]", Value: "ca1", Ok: true},
+		{Path: "data.ca_chain[2]"},
+		{Path: "data.ca_chain[a]"},
+		{Path: "data.certificate[0]"},
+		{Path: "data.certificate.name"},
+		{Path: "data.missing"},
+	}
+	for i, c := range tests {
+		value, err := JSONPath(doc, c.Path)
+		if !c.Ok {
+			assert.Error(t, err, "case %d should have errored", i)
+			continue
+		}
+		assert.NoError(t, err, "case %d should have not errored", i)
+		assert.Equal(t, c.Value, value, "case %d value not as expected", i)
+	}
+}
+
+func TestParseDotenv(t *testing.T) {
+	content := `# a comment
+DB_HOST=localhost
+export DB_USER = admin
+DB_PASSWORD="pa ss\nword"
+DB_NAME='my#db'
+DB_PORT=5432 # the port
+
+EMPTY=
+`
+	values, err := ParseDotenv(content)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"DB_HOST":     "localhost",
+		"DB_USER":     "admin",
+		"DB_PASSWORD": "pa ss\nword",
+		"DB_NAME":     "my#db",
+		"DB_PORT":     "5432",
+		"EMPTY":       "",
+	}, values)
+
+	_, err = ParseDotenv("NOT_A_VALUE")
+	assert.Error(t, err)
+	_, err = ParseDotenv(`BAD="unterminated\"`)
+	assert.Error(t, err)
+}
+
+func TestParseProperties(t *testing.T) {
+	content := `# a comment
+! another comment
+db.host=localhost
+db.user : admin
+db.password   secret
+db.url=jdbc:postgresql://localhost/db
+db.list=a,\
+    b,\
+    c
+key\=with\:separators=value\twith tab
+empty
+`
+	values, err := ParseProperties(content)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"db.host":             "localhost",
+		"db.user":             "admin",
+		"db.password":         "secret",
+		"db.url":              "jdbc:postgresql://localhost/db",
+		"db.list":             "a,b,c",
+		"key=with:separators": "value\twith tab",
+		"empty":               "",
+	}, values)
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		Prefix string
+		Key    string
+		Name   string
+	}{
+		{Key: "password", Name: "PASSWORD"},
+		{Key: "db.password", Name: "DB_PASSWORD"},
+		{Key: "tls-cert", Name: "TLS_CERT"},
+		{Prefix: "APP_", Key: "db.password", Name: "APP_DB_PASSWORD"},
+		{Prefix: "app_", Key: "key", Name: "APP_KEY"},
+		{Key: "1password", Name: "_1PASSWORD"},
+		{Key: "päss", Name: "P__SS"},
+	}
+	for i, c := range tests {
+		assert.Equal(t, c.Name, EnvName(c.Prefix, c.Key), "case %d name not as expected", i)
+	}
+}
+
+func TestIsSameAddress(t *testing.T) {
+	tests := []struct {
+		A    string
+		B    string
+		Same bool
+	}{
+		{A: "http://127.0.0.1:8200", B: "http://127.0.0.1:8200", Same: true},
+		{A: "http://127.0.0.1:8200", B: "http://127.0.0.1:8200/", Same: true},
+		{A: "https://Vault.example.com", B: "https://vault.example.com:443", Same: true},
+		{A: "http://vault.example.com", B: "http://vault.example.com:80", Same: true},
+		{A: "http://127.0.0.1:8200", B: "http://127.0.0.1:8201"},
+		{A: "http://vault.example.com", B: "https://vault.example.com"},
+		{A: "https://vault.example.com", B: "https://vault-dr.example.com"},
+	}
+	for i, c := range tests {
+		assert.Equal(t, c.Same, IsSameAddress(c.A, c.B), "case %d not as expected", i)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/api"
+	"github.com/UKHomeOffice/vaultctl/pkg/utils"
+)
+
+// HasAttributesDrifted reads the current values of the attributes and checks if any of the
+// declared fields differ; if the uri does not exist, or does not support reads, the attributes are
+// considered drifted. The fields absent from the response, i.e. write only fields, are skipped
+func (r *Client) HasAttributesDrifted(uri string, attrs *api.Attributes) (bool, error) {
+	resp, err := r.Request("GET", uri, nil)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read the current attributes from: %s, error: %s", uri, err)
+	}
+
+	var result struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := utils.DecodeConfig(resp.Body, "json", &result); err != nil {
+		return false, fmt.Errorf("failed to decode the current attributes from: %s, error: %s", uri, err)
+	}
+	if result.Data == nil {
+		return true, nil
+	}
+
+	return hasDrifted(attrs.Settings(), result.Data), nil
+}
+
+// hasDrifted checks if any of the declared fields differ from the current values, skipping those
+// not in the current values
+func hasDrifted(declared, current map[string]interface{}) bool {
+	for k, v := range declared {
+		value, found := current[k]
+		if found && !isValueEqual(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WriteAttributes writes the attributes to the uri, returning the decoded response, if any, and an
+// error on a non-2xx response
+func (r *Client) WriteAttributes(method, uri string, attrs *api.Attributes) (map[string]interface{}, error) {
+	resp, err := r.Request(method, uri, attrs.Settings())
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply the attributes to: %s, error: %s", uri, err)
+	}
+	content, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("failed to apply the attributes to: %s, code: %d, body: %s", uri, resp.StatusCode, content)
+	}
+	if len(strings.TrimSpace(string(content))) <= 0 {
+		return nil, nil
+	}
+	response := make(map[string]interface{}, 0)
+	if err := json.Unmarshal(content, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode the response from: %s, error: %s", uri, err)
+	}
+
+	return response, nil
+}
+
+// isValueEqual compares a declared value against the one returned by vault, taking into account
+// vault will return durations as seconds, lists as arrays and numbers as floats
+func isValueEqual(declared, current interface{}) bool {
+	// step: a list on either side is compared as a list, a string being a comma separated list
+	if isList(declared) || isList(current) {
+		a, b := toList(declared), toList(current)
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !isValueEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	a := fmt.Sprintf("%v", declared)
+	b := fmt.Sprintf("%v", current)
+	if a == b {
+		return true
+	}
+
+	// step: compare as durations or numbers
+	x, okA := toSeconds(a)
+	y, okB := toSeconds(b)
+
+	return okA && okB && x == y
+}
+
+// isList checks if the value is a list
+func isList(value interface{}) bool {
+	switch value.(type) {
+	case []interface{}, []string:
+		return true
+	}
+
+	return false
+}
+
+// toList converts a list or a comma separated string into a list of trimmed strings
+func toList(value interface{}) []string {
+	var items []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, x := range v {
+			items = append(items, strings.TrimSpace(fmt.Sprintf("%v", x)))
+		}
+	case []string:
+		for _, x := range v {
+			items = append(items, strings.TrimSpace(x))
+		}
+	default:
+		content := fmt.Sprintf("%v", value)
+		if content == "" {
+			return items
+		}
+		for _, x := range strings.Split(content, ",") {
+			items = append(items, strings.TrimSpace(x))
+		}
+	}
+
+	return items
+}
+
+// toSeconds converts a duration or number to seconds
+func toSeconds(value string) (float64, bool) {
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		return n, true
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return d.Seconds(), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValueEqual(t *testing.T) {
+	tests := []struct {
+		Declared interface{}
+		Current  interface{}
+		Equal    bool
+	}{
+		{Declared: "test", Current: "test", Equal: true},
+		{Declared: "test", Current: "other"},
+		{Declared: true, Current: true, Equal: true},
+		{Declared: "true", Current: false},
+		// numbers
+		{Declared: 10, Current: float64(10), Equal: true},
+		{Declared: "10", Current: float64(10), Equal: true},
+		{Declared: 10, Current: float64(11)},
+		{Declared: 2048, Current: "2048", Equal: true},
+		// durations
+		{Declared: "1h", Current: float64(3600), Equal: true},
+		{Declared: "72h", Current: "259200", Equal: true},
+		{Declared: "1h0m0s", Current: "60m", Equal: true},
+		{Declared: "1h", Current: float64(60)},
+		{Declared: "1h", Current: "test"},
+		// lists
+		{Declared: []interface{}{"a", "b"}, Current: []interface{}{"a", "b"}, Equal: true},
+		{Declared: "a,b", Current: []interface{}{"a", "b"}, Equal: true},
+		{Declared: "a, b", Current: []interface{}{"a", "b"}, Equal: true},
+		{Declared: []interface{}{"a", "b"}, Current: "a,b", Equal: true},
+		{Declared: []string{"a", "b"}, Current: []interface{}{"a", "b"}, Equal: true},
+		{Declared: []interface{}{"1h", 10}, Current: []interface{}{float64(3600), float64(10)}, Equal: true},
+		{Declared: []interface{}{}, Current: []interface{}{}, Equal: true},
+		{Declared: "", Current: []interface{}{}, Equal: true},
+		{Declared: []interface{}{"a", "b"}, Current: []interface{}{"a"}},
+		{Declared: []interface{}{"a", "b"}, Current: []interface{}{"a", "c"}},
+		{Declared: "a", Current: []interface{}{"a", "b"}},
+	}
+	for i, c := range tests {
+		assert.Equal(t, c.Equal, isValueEqual(c.Declared, c.Current), "case %d not as expected", i)
+	}
+}
+
+func TestHasAttributesDrifted(t *testing.T) {
+	tests := []struct {
+		Code    int
+		Body    string
+		Drifted bool
+		Ok      bool
+	}{
+		{Code: http.StatusNotFound, Body: `{"errors":[]}`, Drifted: true, Ok: true},
+		{Code: http.StatusMethodNotAllowed, Body: `{"errors":["unsupported operation"]}`, Drifted: true, Ok: true},
+		{Code: http.StatusForbidden, Body: `{"errors":["permission denied"]}`},
+		{Code: http.StatusInternalServerError, Body: `{"errors":["internal error"]}`},
+		{Code: http.StatusOK, Body: `{"data":null}`, Drifted: true, Ok: true},
+		{Code: http.StatusOK, Body: `{"data":{"max_ttl":3600,"allowed_domains":["example.com"]}}`, Ok: true},
+		{Code: http.StatusOK, Body: `{"data":{"max_ttl":7200,"allowed_domains":["example.com"]}}`, Drifted: true, Ok: true},
+		// the write only field is not returned, so is skipped
+		{Code: http.StatusOK, Body: `{"data":{"max_ttl":3600}}`, Ok: true},
+	}
+	attrs := &api.Attributes{"uri": "roles/test", "max_ttl": "1h", "allowed_domains": "example.com"}
+	for i, c := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(c.Code)
+			w.Write([]byte(c.Body))
+		}))
+		client, err := New(server.URL, "", "", "", "token")
+		if !assert.NoError(t, err) {
+			server.Close()
+			continue
+		}
+		drifted, err := client.HasAttributesDrifted("pki/roles/test", attrs)
+		server.Close()
+		if !c.Ok {
+			assert.Error(t, err, "case %d should have errored", i)
+			continue
+		}
+		assert.NoError(t, err, "case %d should have not errored", i)
+		assert.Equal(t, c.Drifted, drifted, "case %d drift not as expected", i)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/utils"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// catalogAliases are backend types which have been renamed in the catalog
+var catalogAliases = map[string]string{
+	"generic": "kv",
+}
+
+// Catalog is the secret and auth types supported by the vault server
+type Catalog struct {
+	// Secret is a list of the secret backend types
+	Secret []string `json:"secret"`
+	// Auth is a list of the auth backend types
+	Auth []string `json:"auth"`
+}
+
+// HasSecret checks if the server supports the secret backend type
+func (r *Catalog) HasSecret(name string) bool {
+	if alias, found := catalogAliases[name]; found && utils.ContainedIn(alias, r.Secret) {
+		return true
+	}
+
+	return utils.ContainedIn(name, r.Secret)
+}
+
+// HasAuth checks if the server supports the auth backend type
+func (r *Catalog) HasAuth(name string) bool {
+	return utils.ContainedIn(name, r.Auth)
+}
+
+// Catalog retrieves the types supported by the server from the plugin catalog, returning nil if
+// the server does not expose a catalog or the token is not permitted to read it
+func (r *Client) Catalog() (*Catalog, error) {
+	resp, err := r.Request("GET", "sys/plugins/catalog", nil)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed) {
+		return nil, nil
+	}
+	if resp != nil && resp.StatusCode == http.StatusForbidden {
+		log.Warnf("permission denied reading the plugin catalog, unable to confirm the server supports the types")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve the plugin catalog, error: %s", err)
+	}
+
+	var result struct {
+		Data *Catalog `json:"data"`
+	}
+	if err := utils.DecodeConfig(resp.Body, "json", &result); err != nil {
+		return nil, err
+	}
+
+	return result.Data, nil
+}
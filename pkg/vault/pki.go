@@ -0,0 +1,113 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/api"
+)
+
+// GetCA retrieves the pem encoded certificate authority of a pki backend, returning an empty string
+// if the backend does not have one yet
+func (r *Client) GetCA(path string) (string, error) {
+	resp, err := r.Request("GET", fmt.Sprintf("%s/ca/pem", path), nil)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if resp != nil && (resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve the ca for: %s, error: %s", path, err)
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
+
+// GenerateRoot generates a root certificate authority in a pki backend
+func (r *Client) GenerateRoot(path string, ca *api.CertificateAuthority) error {
+	_, err := r.client.Logical().Write(fmt.Sprintf("%s/root/generate/internal", path), caParams(ca))
+
+	return err
+}
+
+// GenerateIntermediate generates an intermediate certificate authority, returning the csr
+func (r *Client) GenerateIntermediate(path string, ca *api.CertificateAuthority) (string, error) {
+	resp, err := r.client.Logical().Write(fmt.Sprintf("%s/intermediate/generate/internal", path), caParams(ca))
+	if err != nil {
+		return "", err
+	}
+	if resp == nil || resp.Data["csr"] == nil {
+		return "", fmt.Errorf("no csr returned by: %s/intermediate/generate/internal", path)
+	}
+
+	return fmt.Sprintf("%s", resp.Data["csr"]), nil
+}
+
+// SignIntermediate signs the csr of an intermediate with a certificate authority, returning the certificate
+func (r *Client) SignIntermediate(signer, csr string, ca *api.CertificateAuthority) (string, error) {
+	params := map[string]interface{}{
+		"csr":         csr,
+		"common_name": ca.CommonName,
+	}
+	if ca.TTL > 0 {
+		params["ttl"] = ca.TTL.String()
+	}
+	resp, err := r.client.Logical().Write(fmt.Sprintf("%s/root/sign-intermediate", signer), params)
+	if err != nil {
+		return "", err
+	}
+	if resp == nil || resp.Data["certificate"] == nil {
+		return "", fmt.Errorf("no certificate returned by: %s/root/sign-intermediate", signer)
+	}
+
+	return fmt.Sprintf("%s", resp.Data["certificate"]), nil
+}
+
+// SetSigned sets the signed certificate of an intermediate certificate authority
+func (r *Client) SetSigned(path, certificate string) error {
+	_, err := r.client.Logical().Write(fmt.Sprintf("%s/intermediate/set-signed", path), map[string]interface{}{
+		"certificate": certificate,
+	})
+
+	return err
+}
+
+// caParams returns the parameters for generating or signing a certificate authority
+func caParams(ca *api.CertificateAuthority) map[string]interface{} {
+	params := map[string]interface{}{
+		"common_name": ca.CommonName,
+	}
+	if ca.TTL > 0 {
+		params["ttl"] = ca.TTL.String()
+	}
+	if ca.KeyType != "" {
+		params["key_type"] = ca.KeyType
+	}
+	if ca.KeyBits > 0 {
+		params["key_bits"] = ca.KeyBits
+	}
+
+	return params
+}
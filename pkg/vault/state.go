@@ -0,0 +1,78 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// oneshotPrefix is the prefix for the keys of executed oneshot attributes
+	oneshotPrefix = "oneshot-"
+)
+
+// State is the record vaultctl keeps in vault of the actions it has taken
+type State struct {
+	// the client used to persist the state
+	client *Client
+	// the path of the state in vault
+	path string
+	// whether the state existed before it was loaded
+	exists bool
+	// the values of the state
+	values map[string]interface{}
+}
+
+// LoadState retrieves the vaultctl state record from vault
+func (r *Client) LoadState(path string) (*State, error) {
+	values, err := r.GetSecret(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve the vaultctl state from: %s, error: %s", path, err)
+	}
+	state := &State{
+		client: r,
+		path:   path,
+		exists: values != nil,
+		values: values,
+	}
+	if state.values == nil {
+		state.values = make(map[string]interface{}, 0)
+	}
+
+	return state, nil
+}
+
+// Exists checks if the state record existed before it was loaded
+func (r *State) Exists() bool {
+	return r.exists
+}
+
+// HasOneshot checks if the oneshot attribute has been executed
+func (r *State) HasOneshot(hash string) bool {
+	_, found := r.values[oneshotPrefix+hash]
+	return found
+}
+
+// RecordOneshot records the oneshot attribute as executed and persists the state
+func (r *State) RecordOneshot(hash, uri string) error {
+	r.values[oneshotPrefix+hash] = fmt.Sprintf("%s executed at %s", uri, time.Now().UTC().Format(time.RFC3339))
+	if _, err := r.client.Client().Logical().Write(r.path, r.values); err != nil {
+		return fmt.Errorf("unable to persist the vaultctl state to: %s, error: %s", r.path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/api"
+	"github.com/UKHomeOffice/vaultctl/pkg/utils"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	// TokenCreated indicates the token was missing and has been created
+	TokenCreated = "created"
+	// TokenRenewed indicates the token was under the renewal threshold and has been renewed
+	TokenRenewed = "renewed"
+	// TokenRecreated indicates the token policies differed and the token was recreated
+	TokenRecreated = "recreated"
+	// TokenUnchanged indicates the token is up to date
+	TokenUnchanged = "unchanged"
+)
+
+// tokenInfo is the details of an existing token
+type tokenInfo struct {
+	// the token id
+	ID string
+	// the accessor for the token
+	Accessor string
+	// the policies associated to the token
+	Policies []string
+	// the remaining ttl of the token
+	TTL time.Duration
+}
+
+// tokenCreateResponse is the response from creating a token
+type tokenCreateResponse struct {
+	Auth struct {
+		ClientToken string `json:"client_token"`
+		Accessor    string `json:"accessor"`
+	} `json:"auth"`
+}
+
+// EnsureToken ensures the token for a user exists, is within it's ttl and has the correct policies,
+// returning the action which was taken
+func (r *Client) EnsureToken(user *api.User) (string, error) {
+	if err := user.IsValid(); err != nil {
+		return "", err
+	}
+	token := user.UserToken
+
+	// step: lookup the existing token
+	current, err := r.lookupUserToken(token)
+	if err != nil {
+		return "", err
+	}
+	if current == nil {
+		return TokenCreated, r.createToken(user)
+	}
+
+	// step: check the policies are the same
+	if !samePolicies(current.Policies, user.Policies) {
+		log.Debugf("token: %s policies differ, current: %s, expected: %s", token.DisplayName, current.Policies, user.Policies)
+		if err := r.revokeToken(current); err != nil {
+			return "", err
+		}
+		return TokenRecreated, r.createToken(user)
+	}
+
+	// step: check if the token requires renewal
+	if token.TTL > 0 && current.TTL < token.GetRenewThreshold() {
+		if _, err := r.client.Auth().Token().Renew(current.ID, int(token.TTL.Seconds())); err != nil {
+			return "", fmt.Errorf("unable to renew the token: %s, error: %s", token.DisplayName, err)
+		}
+		return TokenRenewed, nil
+	}
+
+	return TokenUnchanged, nil
+}
+
+// lookupUserToken retrieves the token for the user, either by the id or the stored accessor
+func (r *Client) lookupUserToken(token *api.UserToken) (*tokenInfo, error) {
+	if token.ID != "" {
+		return r.lookupToken("auth/token/lookup", map[string]string{"token": token.ID}, token.ID)
+	}
+
+	// step: retrieve the stored token and accessor
+	stored, err := r.GetSecret(token.TokenPath)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, nil
+	}
+	accessor, _ := stored["accessor"].(string)
+	id, _ := stored["token"].(string)
+	if accessor == "" || id == "" {
+		return nil, nil
+	}
+
+	return r.lookupToken("auth/token/lookup-accessor", map[string]string{"accessor": accessor}, id)
+}
+
+// lookupToken performs a token lookup, returning nil if the token does not exist
+func (r *Client) lookupToken(uri string, body interface{}, id string) (*tokenInfo, error) {
+	resp, err := r.Request("POST", uri, body)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil && isMissingToken(resp.StatusCode, err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var result struct {
+		Data struct {
+			Accessor string        `json:"accessor"`
+			Policies []interface{} `json:"policies"`
+			TTL      int           `json:"ttl"`
+		} `json:"data"`
+	}
+	if err := utils.DecodeConfig(resp.Body, "json", &result); err != nil {
+		return nil, err
+	}
+	info := &tokenInfo{
+		ID:       id,
+		Accessor: result.Data.Accessor,
+		TTL:      time.Duration(result.Data.TTL) * time.Second,
+	}
+	for _, x := range result.Data.Policies {
+		info.Policies = append(info.Policies, fmt.Sprintf("%s", x))
+	}
+
+	return info, nil
+}
+
+// createToken creates the token for a user, storing the token and accessor if required
+func (r *Client) createToken(user *api.User) error {
+	token := user.UserToken
+	params := &tokenConfig{
+		ID:          token.ID,
+		DisplayName: token.DisplayName,
+		MaxUses:     token.MaxUses,
+		Policies:    user.Policies,
+	}
+	if token.TTL > 0 {
+		params.TTL = token.TTL.String()
+	}
+
+	resp, err := r.Request("POST", fmt.Sprintf("auth/%s/create", user.GetPath()), params)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	result := new(tokenCreateResponse)
+	if err := utils.DecodeConfig(resp.Body, "json", result); err != nil {
+		return err
+	}
+
+	if token.TokenPath != "" {
+		if _, err := r.client.Logical().Write(token.TokenPath, map[string]interface{}{
+			"token":    result.Auth.ClientToken,
+			"accessor": result.Auth.Accessor,
+		}); err != nil {
+			return fmt.Errorf("unable to store the token: %s in: %s, error: %s", token.DisplayName, token.TokenPath, err)
+		}
+	}
+
+	return nil
+}
+
+// RevokeUserToken revokes the token of a user if it exists, removing any stored token, returning
+// true if the token was revoked
+func (r *Client) RevokeUserToken(token *api.UserToken) (bool, error) {
+	current, err := r.lookupUserToken(token)
+	if err != nil {
+		return false, err
+	}
+	if current != nil {
+		if err := r.revokeToken(current); err != nil {
+			return false, err
+		}
+	}
+	if token.TokenPath != "" {
+		stored, err := r.GetSecret(token.TokenPath)
+		if err != nil {
+			return false, err
+		}
+		if stored != nil {
+			if err := r.DeleteSecret(token.TokenPath); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	return current != nil, nil
+}
+
+// revokeToken revokes an existing token
+func (r *Client) revokeToken(token *tokenInfo) error {
+	return r.client.Auth().Token().RevokeTree(token.ID)
+}
+
+// isMissingToken checks if the lookup failed because the token or accessor does not exist, any
+// other failure (i.e. a permission denied) is an error
+func isMissingToken(code int, err error) bool {
+	switch code {
+	case http.StatusNotFound:
+		return true
+	case http.StatusBadRequest:
+		message := strings.ToLower(err.Error())
+		return strings.Contains(message, "bad token") || strings.Contains(message, "invalid accessor")
+	}
+
+	return false
+}
+
+// samePolicies checks the token policies match the expected, ignoring the default policy
+func samePolicies(current, expected []string) bool {
+	var a []string
+	for _, x := range current {
+		if x != "default" || utils.ContainedIn(x, expected) {
+			a = append(a, x)
+		}
+	}
+	b := append([]string{}, expected...)
+	sort.Strings(a)
+	sort.Strings(b)
+
+	return strings.Join(a, ",") == strings.Join(b, ",")
+}
@@ -17,9 +17,14 @@ package vault
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"strconv"
+	"time"
+
+	"github.com/UKHomeOffice/vaultctl/pkg/api"
 )
 
 // Encrypt encrypts the content with a transit endpoint
@@ -78,3 +83,113 @@ func (r *Client) Decrypt(path, key string, reader io.Reader) (string, error) {
 
 	return string(decoded), nil
 }
+
+// TransitKeyInfo is the current state of a transit key
+type TransitKeyInfo struct {
+	// Derived indicates the key supports key derivation
+	Derived bool
+	// DeletionAllowed indicates the key can be deleted
+	DeletionAllowed bool
+	// MinDecryptionVersion is the minimum version permitted to decrypt
+	MinDecryptionVersion int
+	// LatestVersion is the latest version of the key
+	LatestVersion int
+	// Created is the creation time of the latest version
+	Created time.Time
+}
+
+// GetTransitKey retrieves a transit key, returning nil if the key does not exist
+func (r *Client) GetTransitKey(path, name string) (*TransitKeyInfo, error) {
+	resp, err := r.client.Logical().Read(fmt.Sprintf("%s/keys/%s", path, name))
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Data == nil {
+		return nil, nil
+	}
+	info := &TransitKeyInfo{
+		Derived:              resp.Data["derived"] == true,
+		DeletionAllowed:      resp.Data["deletion_allowed"] == true,
+		MinDecryptionVersion: toInt(resp.Data["min_decryption_version"]),
+		LatestVersion:        toInt(resp.Data["latest_version"]),
+	}
+
+	// step: find the creation time of the latest version
+	if keys, found := resp.Data["keys"].(map[string]interface{}); found {
+		info.LatestVersion, info.Created = getLatestKeyVersion(info.LatestVersion, keys)
+	}
+
+	return info, nil
+}
+
+// getLatestKeyVersion returns the latest version of a key and it's creation time, either a unix
+// timestamp or a rfc3339 time; the latest version defaults to the highest version in the keys
+func getLatestKeyVersion(latest int, keys map[string]interface{}) (int, time.Time) {
+	if latest <= 0 {
+		for version := range keys {
+			if v, err := strconv.Atoi(version); err == nil && v > latest {
+				latest = v
+			}
+		}
+	}
+
+	var created time.Time
+	switch x := keys[strconv.Itoa(latest)].(type) {
+	case json.Number:
+		if n, err := x.Int64(); err == nil {
+			created = time.Unix(n, 0)
+		}
+	case float64:
+		created = time.Unix(int64(x), 0)
+	case string:
+		if t, err := time.Parse(time.RFC3339, x); err == nil {
+			created = t
+		}
+	}
+
+	return latest, created
+}
+
+// CreateTransitKey creates a named key in a transit backend
+func (r *Client) CreateTransitKey(path string, key *api.TransitKey) error {
+	_, err := r.client.Logical().Write(fmt.Sprintf("%s/keys/%s", path, key.Name), map[string]interface{}{
+		"derived": key.Derived,
+	})
+
+	return err
+}
+
+// ConfigureTransitKey updates the configuration of a transit key
+func (r *Client) ConfigureTransitKey(path string, key *api.TransitKey) error {
+	params := map[string]interface{}{
+		"deletion_allowed": key.DeletionAllowed,
+	}
+	if key.MinDecryptionVersion > 0 {
+		params["min_decryption_version"] = key.MinDecryptionVersion
+	}
+	_, err := r.client.Logical().Write(fmt.Sprintf("%s/keys/%s/config", path, key.Name), params)
+
+	return err
+}
+
+// RotateTransitKey rotates a transit key to a new version
+func (r *Client) RotateTransitKey(path, name string) error {
+	_, err := r.client.Logical().Write(fmt.Sprintf("%s/keys/%s/rotate", path, name), map[string]interface{}{})
+
+	return err
+}
+
+// toInt converts a numeric value from a response to an int
+func toInt(value interface{}) int {
+	switch x := value.(type) {
+	case json.Number:
+		n, _ := x.Int64()
+		return int(n)
+	case float64:
+		return int(x)
+	case int:
+		return x
+	}
+
+	return 0
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2015 All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLatestKeyVersion(t *testing.T) {
+	tests := []struct {
+		Latest  int
+		Keys    map[string]interface{}
+		Version int
+		Created time.Time
+	}{
+		{
+			Keys:    map[string]interface{}{"1": float64(100), "2": float64(200), "3": float64(300), "10": float64(1000)},
+			Version: 10,
+			Created: time.Unix(1000, 0),
+		},
+		{
+			Latest:  2,
+			Keys:    map[string]interface{}{"1": float64(100), "2": float64(200), "3": float64(300)},
+			Version: 2,
+			Created: time.Unix(200, 0),
+		},
+		{
+			Keys:    map[string]interface{}{"1": json.Number("100"), "2": json.Number("200")},
+			Version: 2,
+			Created: time.Unix(200, 0),
+		},
+		{
+			Keys:    map[string]interface{}{"1": "2017-01-01T00:00:00Z", "2": "2017-06-01T00:00:00Z"},
+			Version: 2,
+			Created: time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Keys:    map[string]interface{}{"invalid": float64(100)},
+			Version: 0,
+		},
+	}
+	for i, c := range tests {
+		// step: run each case repeatedly, as the map order is random
+		for j := 0; j < 10; j++ {
+			version, created := getLatestKeyVersion(c.Latest, c.Keys)
+			assert.Equal(t, c.Version, version, "case %d version not as expected", i)
+			assert.True(t, c.Created.Equal(created), "case %d created not as expected, got: %s", i, created)
+		}
+	}
+}
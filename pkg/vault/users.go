@@ -27,7 +27,7 @@ import (
 )
 
 type userConfig struct {
-	Password string `json:"password"`
+	Password string `json:"password,omitempty"`
 	Policies string `json:"policies"`
 }
 
@@ -39,49 +39,93 @@ type tokenConfig struct {
 	MaxUses     int      `json:"num_uses"`
 }
 
+type ldapUserConfig struct {
+	Groups   string `json:"groups"`
+	Policies string `json:"policies"`
+}
+
+type ldapGroupConfig struct {
+	Policies string `json:"policies"`
+}
+
+type mapConfig struct {
+	Value       string `json:"value"`
+	DisplayName string `json:"display_name,omitempty"`
+	CIDRBlock   string `json:"cidr_block,omitempty"`
+}
+
+type certConfig struct {
+	Certificate string `json:"certificate"`
+	DisplayName string `json:"display_name,omitempty"`
+	Policies    string `json:"policies"`
+	TTL         string `json:"ttl,omitempty"`
+}
+
 // AddUser adds a user to vault
 func (r *Client) AddUser(user *api.User) error {
 	var params interface{}
-	// step: set the path
-	uri := user.Path
+
+	if err := user.IsValid(); err != nil {
+		return err
+	}
+	// step: get the uri of the user
+	uri := user.GetURI()
+	policies := strings.Join(user.Policies, ",")
+
 	// step: provision the type
-	if user.UserPass != nil {
-		if err := user.UserPass.IsValid(); err != nil {
-			return err
-		}
-		// step: use the path or default to the type
-		path := "userpass"
-		if user.Path != "" {
-			path = user.Path
+	switch user.GetType() {
+	case api.UserPassType:
+		// step: if the password is generated and has not been set, we only update the policies
+		if user.UserPass.IsGenerated() {
+			uri = fmt.Sprintf("%s/policies", uri)
 		}
-		uri = fmt.Sprintf("auth/%s/users/%s", path, user.UserPass.Username)
-
 		params = &userConfig{
 			Password: user.UserPass.Password,
-			Policies: strings.Join(user.Policies, ","),
+			Policies: policies,
 		}
-	}
-	if user.UserToken != nil {
-		if err := user.UserToken.IsValid(); err != nil {
-			return err
+	case api.UserTokenType:
+		_, err := r.EnsureToken(user)
+		return err
+	case api.LDAPUserType:
+		params = &ldapUserConfig{
+			Groups:   strings.Join(user.LDAPUser.Groups, ","),
+			Policies: policies,
+		}
+	case api.LDAPGroupType:
+		params = &ldapGroupConfig{
+			Policies: policies,
 		}
-		// step: use the path or default to the type
-		path := "token"
-		if user.Path != "" {
-			path = user.Path
+	case api.GithubUserType, api.GithubTeamType:
+		params = &mapConfig{
+			Value: policies,
 		}
-		uri = fmt.Sprintf("auth/%s/create", path)
-
-		params = &tokenConfig{
-			ID:          user.UserToken.ID,
-			DisplayName: user.UserToken.DisplayName,
-			TTL:         user.UserToken.TTL.String(),
-			MaxUses:     user.UserToken.MaxUses,
-			Policies:    user.Policies,
+	case api.AppIDType:
+		params = &mapConfig{
+			Value:       policies,
+			DisplayName: user.AppID.DisplayName,
 		}
+	case api.UserIDType:
+		params = &mapConfig{
+			Value:     strings.Join(user.UserID.AppIDs, ","),
+			CIDRBlock: user.UserID.CIDRBlock,
+		}
+	case api.CertType:
+		content, err := ioutil.ReadFile(user.Cert.Certificate)
+		if err != nil {
+			return fmt.Errorf("unable to read the certificate: %s, error: %s", user.Cert.Certificate, err)
+		}
+		cert := &certConfig{
+			Certificate: string(content),
+			DisplayName: user.Cert.DisplayName,
+			Policies:    policies,
+		}
+		if user.Cert.TTL > 0 {
+			cert.TTL = user.Cert.TTL.String()
+		}
+		params = cert
 	}
 
-	log.Debugf("adding the user: %s", params)
+	log.Debugf("adding the user: %s", uri)
 
 	resp, err := r.Request("POST", uri, params)
 	if err != nil {
@@ -99,3 +143,39 @@ func (r *Client) AddUser(user *api.User) error {
 
 	return nil
 }
+
+// HasUser checks if the user already exists in the auth backend
+func (r *Client) HasUser(user *api.User) (bool, error) {
+	secret, err := r.client.Logical().Read(user.GetURI())
+	if err != nil {
+		return false, err
+	}
+
+	return secret != nil, nil
+}
+
+// ListUsers retrieves the names of the users in a collection of an auth backend
+func (r *Client) ListUsers(path, collection string) ([]string, error) {
+	return r.ListKeys(fmt.Sprintf("auth/%s/%s", path, collection))
+}
+
+// RemoveUser removes the user from vault if it exists, returning true if it was removed; a token
+// user has it's token revoked
+func (r *Client) RemoveUser(user *api.User) (bool, error) {
+	if user.UserToken != nil {
+		return r.RevokeUserToken(user.UserToken)
+	}
+	found, err := r.HasUser(user)
+	if err != nil || !found {
+		return false, err
+	}
+
+	return true, r.DeleteUser(user.GetPath(), user.GetCollection(), user.Username())
+}
+
+// DeleteUser removes a user from an auth backend
+func (r *Client) DeleteUser(path, collection, name string) error {
+	_, err := r.client.Logical().Delete(fmt.Sprintf("auth/%s/%s/%s", path, collection, name))
+
+	return err
+}
@@ -66,6 +66,9 @@ func New(hostname, username, password, filename, token string) (*Client, error)
 		if err := creds.IsValid(); err != nil {
 			return nil, err
 		}
+		if creds.Password == "" {
+			return nil, fmt.Errorf("the credentials file: %s does not have a password", filename)
+		}
 
 		token, err := service.userLogin(creds)
 		if err != nil {
@@ -97,7 +100,7 @@ func (r *Client) Client() *v.Client {
 
 // AddSecret adds a secret to the vault
 func (r *Client) AddSecret(secret *api.Secret) error {
-	log.Debugf("adding the secret: %s, %v", secret.Path, secret.Values)
+	log.Debugf("adding the secret: %s, keys: %d", secret.Path, len(secret.Values))
 	_, err := r.client.Logical().Write(secret.Path, secret.Values)
 	if err != nil {
 		return err
@@ -106,6 +109,91 @@ func (r *Client) AddSecret(secret *api.Secret) error {
 	return nil
 }
 
+// GetSecret retrieves the values of a secret, returning nil if the secret does not exist
+func (r *Client) GetSecret(path string) (map[string]interface{}, error) {
+	secret, err := r.client.Logical().Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, nil
+	}
+
+	return secret.Data, nil
+}
+
+// DeleteSecret deletes a secret from vault
+func (r *Client) DeleteSecret(path string) error {
+	_, err := r.client.Logical().Delete(path)
+
+	return err
+}
+
+// ListSecrets recursively retrieves the secrets under a path, including the path itself if it's a
+// secret, keyed by the path of the secret
+func (r *Client) ListSecrets(path string) (map[string]map[string]interface{}, error) {
+	list := make(map[string]map[string]interface{}, 0)
+	path = strings.TrimSuffix(path, "/")
+
+	// step: check if the path itself is a secret
+	secret, err := r.GetSecret(path)
+	if err != nil {
+		return list, err
+	}
+	if secret != nil {
+		list[path] = secret
+	}
+
+	keys, err := r.ListKeys(path)
+	if err != nil {
+		return list, err
+	}
+	for _, x := range keys {
+		if strings.HasSuffix(x, "/") {
+			items, err := r.ListSecrets(path + "/" + x)
+			if err != nil {
+				return list, err
+			}
+			for k, v := range items {
+				list[k] = v
+			}
+			continue
+		}
+		// step: the key may have been removed since the listing
+		values, err := r.GetSecret(path + "/" + x)
+		if err != nil {
+			return list, err
+		}
+		if values != nil {
+			list[path+"/"+x] = values
+		}
+	}
+
+	return list, nil
+}
+
+// ListKeys retrieves the keys under a path, returning an empty list if the path does not exist
+func (r *Client) ListKeys(path string) ([]string, error) {
+	var list []string
+
+	resp, err := r.client.Logical().List(path)
+	if err != nil {
+		return list, err
+	}
+	if resp == nil || resp.Data == nil {
+		return list, nil
+	}
+	keys, found := resp.Data["keys"].([]interface{})
+	if !found {
+		return list, nil
+	}
+	for _, x := range keys {
+		list = append(list, fmt.Sprintf("%s", x))
+	}
+
+	return list, nil
+}
+
 // Mounts is a list of mounts
 func (r *Client) Mounts() (map[string]*v.MountOutput, error) {
 	return r.client.Sys().ListMounts()
@@ -136,16 +224,19 @@ func (r *Client) SetPolicy(name, policy string) error {
 func (r *Client) Request(method, uri string, body interface{}) (*http.Response, error) {
 	url := fmt.Sprintf("/%s/%s", apiVersion, strings.TrimPrefix(uri, "/"))
 
-	log.Debugf("make request to %s %s, body: %#v", method, url, body)
+	log.Debugf("make request to %s %s", method, url)
 	// step: create a request
 	request := r.client.NewRequest(method, url)
 	if err := request.SetJSONBody(body); err != nil {
 		return nil, err
 	}
 
-	// step: make the request
+	// step: make the request, keeping the response on an error so the caller can check the status
 	resp, err := r.client.RawRequest(request)
 	if err != nil {
+		if resp != nil {
+			return resp.Response, err
+		}
 		return nil, err
 	}
 